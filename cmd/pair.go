@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"mkvtea/internal/mkv"
+)
+
+var pairWrite bool
+
+func init() {
+	pairCmd.Flags().BoolVarP(&pairWrite, "write", "w", false, "Write the draft manifest to --pairing (default: <dir>/mkvtea_pairing.json)")
+	rootCmd.AddCommand(pairCmd)
+}
+
+// pairCmd generates a draft pairing manifest from the automatic merge heuristics
+var pairCmd = &cobra.Command{
+	Use:   "pair [dir]",
+	Short: "Generate a draft pairing manifest for merge",
+	Long:  "Runs the automatic merge discovery on every video and prints the resulting video ↔ subtitle ↔ audio ↔ fonts pairing.\nWith --write the draft is saved so it can be reviewed, corrected and passed back to merge with --pairing.",
+	Args:  cobra.MaximumNArgs(1),
+	Example: `  mkvtea pair . -r -l ita
+  mkvtea pair /path/to/anime -r --write --pairing pairing.csv
+  mkvtea merge /path/to/anime -r --pairing pairing.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			fmt.Printf("❌ Failed to resolve directory: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.Dir = absDir
+		cfg.Mode = "merge"
+		normalizeLanguages(&cfg)
+
+		files := ScanFiles(cfg.Dir, cfg.Recursive)
+		if len(files) == 0 {
+			fmt.Printf("❌ No MKV files found in: %s\n", cfg.Dir)
+			return
+		}
+
+		manifest := &mkv.Manifest{}
+		for _, file := range files {
+			entry, notes := mkv.DiscoverAssets(file, cfg)
+			manifest.Entries = append(manifest.Entries, entry)
+			for _, note := range notes {
+				fmt.Fprintln(os.Stderr, note)
+			}
+		}
+
+		if !pairWrite {
+			data, err := json.MarshalIndent(manifest, "", "  ")
+			if err != nil {
+				fmt.Printf("❌ Failed to encode manifest: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		out := cfg.Pairing
+		if out == "" {
+			out = filepath.Join(cfg.Dir, "mkvtea_pairing.json")
+		}
+		if err := manifest.Write(out); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📝 Draft manifest with %d entries written to %s\n", len(manifest.Entries), out)
+	},
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"mkvtea/internal/mkv"
+)
+
+func init() {
+	rootCmd.AddCommand(parseCmd)
+}
+
+// parseCmd shows how file names resolve to episodes, to test patterns before a batch
+var parseCmd = &cobra.Command{
+	Use:   "parse <files...>",
+	Short: "Show the episode each file name resolves to",
+	Long:  "Parses file names with the episode pattern (if any) and the built-in heuristics,\nprinting the detected season, episode, title and release group without touching the files.",
+	Args:  cobra.MinimumNArgs(1),
+	Example: `  mkvtea parse *.mkv
+  mkvtea parse --episode-pattern '^(?P<title>.+?) Ep(?P<episode>\d+)' "My Show Ep12.mkv"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// The pattern was validated by loadConfigFile
+		var pattern *mkv.EpisodePattern
+		if cfg.EpisodePattern != "" {
+			pattern, _ = mkv.CompileEpisodePattern(cfg.EpisodePattern)
+		}
+
+		for _, file := range args {
+			name := filepath.Base(file)
+			fmt.Printf("📄 %s\n", name)
+
+			rel, ok := mkv.ParseRelease(name, pattern)
+			if !ok {
+				fmt.Println("   ❌ No episode number found (files are named XX)")
+				continue
+			}
+			fmt.Print(formatRelease(rel))
+		}
+	},
+}
+
+// formatRelease renders the fields of a parsed release, one per line
+func formatRelease(rel mkv.Release) string {
+	ep := rel.Episode
+	season := "-"
+	if ep.Season > 0 {
+		season = fmt.Sprintf("%d", ep.Season)
+	}
+	episode := fmt.Sprintf("%d", ep.Episode)
+	if ep.EndEpisode > 0 {
+		episode += fmt.Sprintf("-%d", ep.EndEpisode)
+	}
+	if ep.Special {
+		episode += " (special)"
+	}
+
+	out := fmt.Sprintf("   Key:      %s (%s)\n", ep.Key(), rel.ParsedBy)
+	out += fmt.Sprintf("   Season:   %s\n", season)
+	out += fmt.Sprintf("   Episode:  %s\n", episode)
+	if ep.Version > 0 {
+		out += fmt.Sprintf("   Version:  v%d\n", ep.Version)
+	}
+	out += fmt.Sprintf("   Title:    %s\n", orDash(rel.Title))
+	out += fmt.Sprintf("   Group:    %s\n", orDash(rel.Group))
+	out += fmt.Sprintf("   Res:      %s\n", orDash(rel.Resolution))
+	out += fmt.Sprintf("   Source:   %s\n", orDash(rel.Source))
+	out += fmt.Sprintf("   CRC32:    %s\n", orDash(rel.CRC32))
+	return out
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
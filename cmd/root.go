@@ -22,11 +22,14 @@ var rootCmd = &cobra.Command{
 	Short:   "🍵 Advanced MKV Tool with TUI (Extract/Merge)",
 	Long:    `MKVTea is a blazing fast batch processing tool for managing your Anime/TV Series library.`,
 	Version: config.Version,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		loadConfigFile(cmd)
+	},
 }
 
 func init() {
 	// --- GLOBAL FLAGS ---
-	rootCmd.PersistentFlags().StringVarP(&cfg.Lang, "lang", "l", "ita", "Target subtitle language code (ita, eng, jpn, etc.)")
+	rootCmd.PersistentFlags().StringVarP(&cfg.Lang, "lang", "l", "ita", "Target subtitle language code (ita, eng, jpn, etc.), IETF tag (pt-BR, es-419) or all (extract only)")
 	rootCmd.PersistentFlags().StringVarP(&cfg.OutDir, "output", "o", "", "Custom output directory (optional)")
 	rootCmd.PersistentFlags().StringVarP(&cfg.SubsDir, "subs-dir", "s", "", "Custom directory for external subtitles (merge mode only)")
 	rootCmd.PersistentFlags().StringVar(&cfg.AudioDir, "audio-dir", "", "Custom directory for external audio (merge mode only)")
@@ -34,13 +37,26 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&cfg.Audio, "audio", "a", false, "Extract or merge audio tracks of the target language")
 	rootCmd.PersistentFlags().StringVar(&cfg.KeepOnlyAudio, "keep-only-audio", "", "Keep only this audio language (removes all others)")
 	rootCmd.PersistentFlags().IntVarP(&cfg.CheckpointInterval, "checkpoint-interval", "", 10, "Save checkpoint every N files (0 to disable)")
+	rootCmd.PersistentFlags().StringVar(&cfg.ConfigFile, "config", config.DefaultFilePath(), "Path of the JSON config file")
+	rootCmd.PersistentFlags().StringVar(&cfg.EpisodePattern, "episode-pattern", "", "Custom episode regex with named groups (season, episode, title, group)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Chapters, "chapters", "", "Extract chapters per episode in this format: xml, simple (extract mode only)")
+	rootCmd.PersistentFlags().StringVar(&cfg.ChaptersDir, "chapters-dir", "", "Directory of chapter files (XML or simple) matched by episode (merge mode only)")
+	rootCmd.PersistentFlags().StringVar(&cfg.NameTemplate, "name-template", "", "Extracted file name template: {show} {season} {episode} {lang} {track_id} {track_name} {forced} {codec_ext}")
+	rootCmd.PersistentFlags().StringVar(&cfg.Undefined, "undefined", "include", "Subtitle tracks tagged \"und\": ignore, include (first language), guess (from the text), as:<lang> (extract mode only)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Convert, "convert", "", "Convert extracted text subtitles to srt, vtt or ass (extract mode only)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.ConvertReplace, "convert-replace", false, "Replace the original subtitle with the converted one instead of keeping both")
+	rootCmd.PersistentFlags().Int64Var(&cfg.SubDelay, "sub-delay", 0, "Shift external subtitles by this many milliseconds, negative to show them earlier (merge mode only)")
+	rootCmd.PersistentFlags().StringVar(&cfg.SubFPS, "sub-fps", "", "Retime external subtitles from one framerate to another, e.g. 25:23.976 (merge mode only)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Match, "match", "auto", "Merge matching strategy: auto (episode number, fuzzy when missing), episode, fuzzy")
+	rootCmd.PersistentFlags().Float64Var(&cfg.MatchThreshold, "match-threshold", mkv.DefaultMatchThreshold, "Minimum name similarity (0-1) accepted by fuzzy matching")
+	rootCmd.PersistentFlags().StringVar(&cfg.Pairing, "pairing", "", "Pairing manifest (.json/.csv) mapping videos to subtitles, audio and fonts (merge mode only)")
 
 	// --- SUBCOMMANDS ---
 
 	// Extract (Alias: e)
 	rootCmd.AddCommand(createCmd("extract", "e",
 		"(e) Extract subtitles, audio, and fonts from MKV files",
-		"Extracts internal subtitles (SRT/ASS), audio tracks, and attached fonts from MKV files.\nOrganizes extracted files into a local 'subs' directory for each video."))
+		"Extracts internal subtitles (SRT/ASS/SSA/WebVTT/USF, PGS .sup, VobSub .idx/.sub), audio tracks, and attached fonts from MKV files.\nMP4/M4V files are remuxed to a temporary MKV first (mov_text subtitles become SRT).\nOrganizes extracted files into a local 'subs' directory for each video."))
 
 	// Merge (Alias: m)
 	rootCmd.AddCommand(createCmd("merge", "m",
@@ -48,6 +64,61 @@ func init() {
 		"Merges external subtitles and audio tracks back into MKV files with proper language and default track settings.\nSupports audio track filtering and font embedding."))
 }
 
+// loadConfigFile applies the config file to cfg and validates the resulting settings
+func loadConfigFile(cmd *cobra.Command) {
+	fileCfg, err := config.LoadFile(cfg.ConfigFile)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	fileCfg.Apply(&cfg, cmd.Flags().Changed)
+
+	switch cfg.Match {
+	case "auto", "episode", "fuzzy":
+	default:
+		fmt.Printf("❌ Invalid --match %q (use auto, episode or fuzzy)\n", cfg.Match)
+		os.Exit(1)
+	}
+	if cfg.MatchThreshold <= 0 || cfg.MatchThreshold > 1 {
+		fmt.Printf("❌ Invalid --match-threshold %v (must be between 0 and 1)\n", cfg.MatchThreshold)
+		os.Exit(1)
+	}
+
+	if err := mkv.ValidateUndefinedPolicy(cfg.Undefined); err != nil {
+		fmt.Printf("❌ Invalid --undefined: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := mkv.ValidateConvertFormat(cfg.Convert); err != nil {
+		fmt.Printf("❌ Invalid --convert: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := mkv.ValidateSubFPS(cfg.SubFPS); err != nil {
+		fmt.Printf("❌ Invalid --sub-fps: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := mkv.ValidateChapterFormat(cfg.Chapters); err != nil {
+		fmt.Printf("❌ Invalid --chapters: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.NameTemplate != "" {
+		if err := mkv.ValidateNameTemplate(cfg.NameTemplate); err != nil {
+			fmt.Printf("❌ Invalid name template %q: %v\n", cfg.NameTemplate, err)
+			os.Exit(1)
+		}
+	}
+
+	if cfg.EpisodePattern != "" {
+		if _, err := mkv.CompileEpisodePattern(cfg.EpisodePattern); err != nil {
+			fmt.Printf("❌ Invalid episode pattern %q: %v\n", cfg.EpisodePattern, err)
+			os.Exit(1)
+		}
+	}
+}
+
 // createCmd generates extract/merge commands with proper descriptions
 func createCmd(mode, alias, short, long string) *cobra.Command {
 	return &cobra.Command{
@@ -58,28 +129,33 @@ func createCmd(mode, alias, short, long string) *cobra.Command {
 		Args:    cobra.MaximumNArgs(1),
 		Example: fmt.Sprintf("  mkvtea %s . -r -l ita -a\n  mkvtea %s /path/to/anime -r -l eng", alias, alias),
 		Run: func(cmd *cobra.Command, args []string) {
-			cfg.Mode = mode
-			if len(args) > 0 {
-				cfg.Dir = args[0]
-			} else {
-				dir, err := os.Getwd()
-				if err != nil {
-					fmt.Printf("❌ Failed to get current directory: %v\n", err)
-					os.Exit(1)
-				}
-				cfg.Dir = dir
-			}
+			runMode(mode, args)
+		},
+	}
+}
 
-			// Ensure Dir is an absolute path to avoid issues with "." or relative paths
-			// when calculating output directory names.
-			absDir, err := filepath.Abs(cfg.Dir)
-			if err == nil {
-				cfg.Dir = absDir
-			}
+// runMode resolves the target directory from args and processes it in the given mode
+func runMode(mode string, args []string) {
+	cfg.Mode = mode
+	if len(args) > 0 {
+		cfg.Dir = args[0]
+	} else {
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Printf("❌ Failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.Dir = dir
+	}
 
-			processFiles(cfg)
-		},
+	// Ensure Dir is an absolute path to avoid issues with "." or relative paths
+	// when calculating output directory names.
+	absDir, err := filepath.Abs(cfg.Dir)
+	if err == nil {
+		cfg.Dir = absDir
 	}
+
+	processFiles(cfg)
 }
 
 // calculateOptimalWorkers calculates optimal number of parallel workers based on CPU count
@@ -95,12 +171,50 @@ func calculateOptimalWorkers() int {
 	return maxProcs
 }
 
+// normalizeLanguages validates --lang and --keep-only-audio and rewrites them as the codes
+// used for matching tracks and naming folders, so that every command agrees on them
+func normalizeLanguages(c *config.Config) {
+	// Parse multiple languages from Lang flag (e.g., "ita,eng,jpn")
+	if c.Lang != "" {
+		c.Languages = strings.Split(c.Lang, ",")
+		// Normalize each language (it, italian → ita; pt-br → pt-BR)
+		for i, lang := range c.Languages {
+			if strings.EqualFold(lang, mkv.AllLanguages) && len(c.Languages) == 1 {
+				if c.Mode != "extract" {
+					fmt.Println("❌ --lang all is only supported by extract")
+					os.Exit(1)
+				}
+				c.Languages[i] = mkv.AllLanguages
+				continue
+			}
+			normalized, err := mkv.NormalizeLanguage(lang)
+			if err != nil {
+				fmt.Printf("❌ Invalid --lang: %v\n", err)
+				os.Exit(1)
+			}
+			c.Languages[i] = normalized
+		}
+		c.Lang = strings.Join(c.Languages, ",")
+	}
+	keepOnlyAudio, err := mkv.NormalizeLanguage(c.KeepOnlyAudio)
+	if err != nil {
+		fmt.Printf("❌ Invalid --keep-only-audio: %v\n", err)
+		os.Exit(1)
+	}
+	c.KeepOnlyAudio = keepOnlyAudio
+}
+
 // processFiles processes MKV files based on the configuration
 func processFiles(cfg config.Config) {
-	// Validate dependencies first
-	if err := mkv.ValidateDependencies(); err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+	// Validate dependencies first (verify only reads files)
+	if cfg.Mode != "verify" {
+		if err := mkv.ValidateDependencies(cfg.Mode); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+	if missing := mkv.MissingTools(); cfg.Mode == "extract" && len(missing) > 0 {
+		fmt.Printf("⚠️ Missing %s: only text subtitles of MKV files can be extracted\n", strings.Join(missing, ", "))
 	}
 
 	// Auto-detect optimal worker count if not explicitly set
@@ -108,12 +222,13 @@ func processFiles(cfg config.Config) {
 		cfg.MaxProcs = calculateOptimalWorkers()
 	}
 
-	// Parse multiple languages from Lang flag (e.g., "ita,eng,jpn")
-	if cfg.Lang != "" {
-		cfg.Languages = strings.Split(cfg.Lang, ",")
-		// Trim whitespace from each language
-		for i, lang := range cfg.Languages {
-			cfg.Languages[i] = strings.TrimSpace(lang)
+	normalizeLanguages(&cfg)
+
+	// Validate the pairing manifest before starting the batch
+	if cfg.Mode == "merge" && cfg.Pairing != "" {
+		if _, err := mkv.LoadManifest(cfg.Pairing); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
 		}
 	}
 
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"testing"
+
+	"mkvtea/internal/config"
+)
+
+func TestNormalizeLanguages(t *testing.T) {
+	c := config.Config{Mode: "merge", Lang: "it,por-BR", KeepOnlyAudio: "japanese"}
+	normalizeLanguages(&c)
+
+	if c.Lang != "ita,pt-BR" || len(c.Languages) != 2 {
+		t.Errorf("Lang = %q, Languages = %v; want ita,pt-BR", c.Lang, c.Languages)
+	}
+	if c.KeepOnlyAudio != "jpn" {
+		t.Errorf("KeepOnlyAudio = %q; want jpn", c.KeepOnlyAudio)
+	}
+}
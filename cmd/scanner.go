@@ -1,17 +1,22 @@
 package cmd
 
 import (
+	"mkvtea/internal/mkv"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
 func isVideoFile(filename string) bool {
+	// Temporary MKV of an MP4 remux, never a video of the library
+	if strings.HasPrefix(filepath.Base(filename), mkv.TempPrefix) {
+		return false
+	}
 	ext := strings.ToLower(filepath.Ext(filename))
-	return ext == ".mkv" || ext == ".mp4"
+	return ext == ".mkv" || ext == ".mp4" || ext == ".m4v"
 }
 
-// ScanFiles finds all .mkv or .mp4 files in the given directory or a single file if specified
+// ScanFiles finds all .mkv, .mp4 or .m4v files in the given directory or a single file if specified
 func ScanFiles(path string, recursive bool) []string {
 	var files []string
 
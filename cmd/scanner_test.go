@@ -33,7 +33,8 @@ func TestScanFilesNonRecursive(t *testing.T) {
 		"video2.mkv",
 		"document.txt",
 		"image.jpg",
-		"video3.MKV", // Test case-insensitive extension
+		"video3.MKV",         // Test case-insensitive extension
+		".mkvtea-123456.mkv", // Leftover of an interrupted MP4 remux
 	}
 
 	for _, f := range testFiles {
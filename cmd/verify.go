@@ -0,0 +1,20 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
+
+// verifyCmd checks files against the CRC32 embedded in their names
+var verifyCmd = &cobra.Command{
+	Use:     "verify [dir]",
+	Aliases: []string{"v"},
+	Short:   "(v) Verify files against the CRC32 in their names",
+	Long:    "Computes the CRC32 of every scanned file and compares it with the hash embedded in the\nfile name (e.g. [ABCD1234]). Files without a hash in their name are reported as unhashed.",
+	Args:    cobra.MaximumNArgs(1),
+	Example: "  mkvtea v . -r\n  mkvtea verify /path/to/anime -r --checkpoint-interval 5",
+	Run: func(cmd *cobra.Command, args []string) {
+		runMode("verify", args)
+	},
+}
@@ -13,6 +13,19 @@ type Config struct {
 	Recursive          bool
 	KeepOnlyAudio      string
 	Audio              bool
-	MaxProcs           int // Concurrency workers (auto-detected based on CPU count, 50% with min 2 and max 8)
-	CheckpointInterval int // Save checkpoint every N files (0 = disabled)
+	MaxProcs           int     // Concurrency workers (auto-detected based on CPU count, 50% with min 2 and max 8)
+	CheckpointInterval int     // Save checkpoint every N files (0 = disabled)
+	ConfigFile         string  // Path of the JSON config file
+	EpisodePattern     string  // Custom episode regex with named groups (season, episode, title, group)
+	Pairing            string  // Manifest (.json/.csv) mapping videos to subtitles, audio and fonts (merge mode)
+	Chapters           string  // Chapter format written by extract: "xml", "simple" ("" = none)
+	ChaptersDir        string  // Directory of external chapter files matched by episode (merge mode)
+	NameTemplate       string  // Extracted file name template ({show}, {season}, {episode}, {lang}, ...)
+	Match              string  // Merge matching strategy: "auto", "episode", "fuzzy"
+	MatchThreshold     float64 // Minimum name similarity (0-1) accepted by fuzzy matching
+	Undefined          string  // Policy for subtitle tracks tagged "und": "ignore", "include", "guess", "as:<lang>"
+	Convert            string  // Format extracted text subtitles are converted to: "srt", "vtt", "ass" ("" = none)
+	ConvertReplace     bool    // Remove the original subtitle after conversion
+	SubDelay           int64   // Milliseconds added to external subtitle timestamps (merge mode)
+	SubFPS             string  // Framerate conversion of external subtitles: "from:to" ("" = none)
 }
@@ -1,6 +1,10 @@
 package config
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestConfigDefaults(t *testing.T) {
 	cfg := Config{
@@ -87,3 +91,34 @@ func TestConfigConcurrency(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"episode_pattern": "Ep(?P<episode>\\d+)"}`), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	fc, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	// File values apply only when the flag was not set
+	cfg := Config{}
+	fc.Apply(&cfg, func(string) bool { return false })
+	if cfg.EpisodePattern != `Ep(?P<episode>\d+)` {
+		t.Errorf("Expected episode pattern from file, got %q", cfg.EpisodePattern)
+	}
+
+	cfg = Config{EpisodePattern: "flag"}
+	fc.Apply(&cfg, func(name string) bool { return name == "episode-pattern" })
+	if cfg.EpisodePattern != "flag" {
+		t.Errorf("Expected flag to take precedence, got %q", cfg.EpisodePattern)
+	}
+
+	// Missing files are not an error
+	if _, err := LoadFile(filepath.Join(dir, "missing.json")); err != nil {
+		t.Errorf("Expected no error for missing file, got %v", err)
+	}
+}
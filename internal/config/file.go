@@ -0,0 +1,55 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileConfig holds the settings that can be stored in the mkvtea config file.
+// Command-line flags always take precedence over file values.
+type FileConfig struct {
+	EpisodePattern string `json:"episode_pattern"`
+	NameTemplate   string `json:"name_template"`
+}
+
+// DefaultFilePath returns the default config file location (e.g. ~/.config/mkvtea/config.json)
+func DefaultFilePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mkvtea", "config.json")
+}
+
+// LoadFile reads a JSON config file. A missing file yields an empty configuration.
+func LoadFile(path string) (*FileConfig, error) {
+	var fc FileConfig
+	if path == "" {
+		return &fc, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &fc, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &fc, nil
+}
+
+// Apply copies file values into cfg for every setting whose flag was not set explicitly
+func (fc *FileConfig) Apply(cfg *Config, flagChanged func(name string) bool) {
+	if fc.EpisodePattern != "" && !flagChanged("episode-pattern") {
+		cfg.EpisodePattern = fc.EpisodePattern
+	}
+	if fc.NameTemplate != "" && !flagChanged("name-template") {
+		cfg.NameTemplate = fc.NameTemplate
+	}
+}
@@ -0,0 +1,60 @@
+package mkv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// chapterFormats maps each supported chapter format to the extension of its files
+var chapterFormats = map[string]string{
+	"xml":    ".xml", // Matroska XML chapters
+	"simple": ".txt", // Simple OGM format (CHAPTER01=00:00:00.000 / CHAPTER01NAME=...)
+}
+
+// ValidateChapterFormat checks a --chapters value
+func ValidateChapterFormat(format string) error {
+	if _, ok := chapterFormats[format]; !ok && format != "" {
+		return fmt.Errorf("unknown chapter format %q (use xml or simple)", format)
+	}
+	return nil
+}
+
+// chapterFileName returns the name of the chapter file written for an episode.
+// Unnumbered videos (movies, specials) share the XX key, so their chapters are named
+// after the video instead.
+func chapterFileName(epKey, video, format string) string {
+	return chapterPrefix(epKey, video) + chapterFormats[format]
+}
+
+func chapterPrefix(epKey, video string) string {
+	if epKey == "XX" {
+		epKey = strings.TrimSuffix(filepath.Base(video), filepath.Ext(video))
+	}
+	return epKey + "_chapters"
+}
+
+// findVideoChapters returns the chapter file of an unnumbered video in dir, "" if none
+func findVideoChapters(dir, video string) string {
+	for _, ext := range []string{".xml", ".txt"} {
+		path := filepath.Join(dir, chapterPrefix("XX", video)+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// chapterArgs returns the mkvextract arguments writing the chapters of a file to outputPath
+func chapterArgs(format, outputPath string) []string {
+	if format == "simple" {
+		return []string{"chapters", "--simple", outputPath}
+	}
+	return []string{"chapters", outputPath}
+}
+
+func isChapterExt(ext string) bool {
+	ext = strings.ToLower(ext)
+	return ext == ".xml" || ext == ".txt"
+}
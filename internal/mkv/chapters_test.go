@@ -0,0 +1,65 @@
+package mkv
+
+import (
+	"encoding/json"
+	"mkvtea/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChapterArgs(t *testing.T) {
+	if got := strings.Join(chapterArgs("xml", "05_chapters.xml"), " "); got != "chapters 05_chapters.xml" {
+		t.Errorf("xml args = %q", got)
+	}
+	if got := strings.Join(chapterArgs("simple", "05_chapters.txt"), " "); got != "chapters --simple 05_chapters.txt" {
+		t.Errorf("simple args = %q", got)
+	}
+	if err := ValidateChapterFormat("ogm"); err == nil {
+		t.Errorf("expected an error for an unknown chapter format")
+	}
+}
+
+func TestChaptersStructure(t *testing.T) {
+	var info Info
+	if err := json.Unmarshal([]byte(`{"tracks": [], "chapters": [{"num_entries": 6}]}`), &info); err != nil {
+		t.Fatalf("Failed to unmarshal info: %v", err)
+	}
+	if len(info.Chapters) != 1 || info.Chapters[0].NumEntries != 6 {
+		t.Errorf("unexpected chapters: %+v", info.Chapters)
+	}
+}
+
+func TestDiscoverChapters(t *testing.T) {
+	dir := t.TempDir()
+	chaptersDir := filepath.Join(dir, "chapters")
+	if err := os.MkdirAll(chaptersDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	for _, name := range []string{
+		chapterFileName("S01E05", "Show S01E05.mkv", "xml"),
+		chapterFileName("S01E06", "Show S01E06.mkv", "simple"),
+		chapterFileName("XX", filepath.Join(dir, "My Movie.mkv"), "xml"),
+		chapterFileName("XX", filepath.Join(dir, "Other Movie.mkv"), "simple"),
+	} {
+		if err := os.WriteFile(filepath.Join(chaptersDir, name), []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	cfg := config.Config{Lang: "ita", ChaptersDir: chaptersDir}
+	for video, expected := range map[string]string{
+		"Show S01E05.mkv": "S01E05_chapters.xml",
+		"Show S01E06.mkv": "S01E06_chapters.txt",
+		"Show S01E07.mkv": "",
+		"My Movie.mkv":    "My Movie_chapters.xml",
+		"Other Movie.mkv": "Other Movie_chapters.txt",
+		"Third Movie.mkv": "",
+	} {
+		entry, _ := DiscoverAssets(filepath.Join(dir, video), cfg)
+		if filepath.Base(entry.Chapters) != expected && !(expected == "" && entry.Chapters == "") {
+			t.Errorf("DiscoverAssets(%q).Chapters = %q; want %q", video, entry.Chapters, expected)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package mkv
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// legacyCharset is a Windows code page still common in fan-made subtitles
+type legacyCharset struct {
+	name    string    // Name understood by mkvmerge --sub-charset
+	table   [128]rune // Runes of the bytes 0x80-0xFF
+	letters string    // Accented letters of the languages written with the code page
+}
+
+// legacyCharsets are tried in order; ties go to the first one. Tables generated
+// from Python's cp1252 and cp1250 codecs, undefined bytes map to U+FFFD.
+var legacyCharsets = []legacyCharset{
+	{
+		name: "windows-1252",
+		table: [128]rune{
+			0x20AC, 0xFFFD, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+			0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0xFFFD, 0x017D, 0xFFFD,
+			0xFFFD, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+			0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0xFFFD, 0x017E, 0x0178,
+			0x00A0, 0x00A1, 0x00A2, 0x00A3, 0x00A4, 0x00A5, 0x00A6, 0x00A7,
+			0x00A8, 0x00A9, 0x00AA, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x00AF,
+			0x00B0, 0x00B1, 0x00B2, 0x00B3, 0x00B4, 0x00B5, 0x00B6, 0x00B7,
+			0x00B8, 0x00B9, 0x00BA, 0x00BB, 0x00BC, 0x00BD, 0x00BE, 0x00BF,
+			0x00C0, 0x00C1, 0x00C2, 0x00C3, 0x00C4, 0x00C5, 0x00C6, 0x00C7,
+			0x00C8, 0x00C9, 0x00CA, 0x00CB, 0x00CC, 0x00CD, 0x00CE, 0x00CF,
+			0x00D0, 0x00D1, 0x00D2, 0x00D3, 0x00D4, 0x00D5, 0x00D6, 0x00D7,
+			0x00D8, 0x00D9, 0x00DA, 0x00DB, 0x00DC, 0x00DD, 0x00DE, 0x00DF,
+			0x00E0, 0x00E1, 0x00E2, 0x00E3, 0x00E4, 0x00E5, 0x00E6, 0x00E7,
+			0x00E8, 0x00E9, 0x00EA, 0x00EB, 0x00EC, 0x00ED, 0x00EE, 0x00EF,
+			0x00F0, 0x00F1, 0x00F2, 0x00F3, 0x00F4, 0x00F5, 0x00F6, 0x00F7,
+			0x00F8, 0x00F9, 0x00FA, 0x00FB, 0x00FC, 0x00FD, 0x00FE, 0x00FF,
+		},
+		letters: "àèéìòùáíóúâêîôûäëïöüçñßÀÈÉÌÒÙÁÍÓÚÂÊÎÔÛÄËÏÖÜÇÑ",
+	},
+	{
+		name: "windows-1250",
+		table: [128]rune{
+			0x20AC, 0xFFFD, 0x201A, 0xFFFD, 0x201E, 0x2026, 0x2020, 0x2021,
+			0xFFFD, 0x2030, 0x0160, 0x2039, 0x015A, 0x0164, 0x017D, 0x0179,
+			0xFFFD, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+			0xFFFD, 0x2122, 0x0161, 0x203A, 0x015B, 0x0165, 0x017E, 0x017A,
+			0x00A0, 0x02C7, 0x02D8, 0x0141, 0x00A4, 0x0104, 0x00A6, 0x00A7,
+			0x00A8, 0x00A9, 0x015E, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x017B,
+			0x00B0, 0x00B1, 0x02DB, 0x0142, 0x00B4, 0x00B5, 0x00B6, 0x00B7,
+			0x00B8, 0x0105, 0x015F, 0x00BB, 0x013D, 0x02DD, 0x013E, 0x017C,
+			0x0154, 0x00C1, 0x00C2, 0x0102, 0x00C4, 0x0139, 0x0106, 0x00C7,
+			0x010C, 0x00C9, 0x0118, 0x00CB, 0x011A, 0x00CD, 0x00CE, 0x010E,
+			0x0110, 0x0143, 0x0147, 0x00D3, 0x00D4, 0x0150, 0x00D6, 0x00D7,
+			0x0158, 0x016E, 0x00DA, 0x0170, 0x00DC, 0x00DD, 0x0162, 0x00DF,
+			0x0155, 0x00E1, 0x00E2, 0x0103, 0x00E4, 0x013A, 0x0107, 0x00E7,
+			0x010D, 0x00E9, 0x0119, 0x00EB, 0x011B, 0x00ED, 0x00EE, 0x010F,
+			0x0111, 0x0144, 0x0148, 0x00F3, 0x00F4, 0x0151, 0x00F6, 0x00F7,
+			0x0159, 0x016F, 0x00FA, 0x0171, 0x00FC, 0x00FD, 0x0163, 0x02D9,
+		},
+		letters: "ąćęłńóśźżčďěňřšťůžýáéíúőűăâîşţđľĺôäĄĆĘŁŃÓŚŹŻČĎĚŇŘŠŤŮŽÝÁÉÍÚŐŰĂÂÎŞŢĐĽĹÔÄ",
+	},
+}
+
+// detectCharset returns the character set of a subtitle file: "UTF-8" (with or without
+// BOM), "UTF-16LE"/"UTF-16BE" when a BOM says so, otherwise the legacy code page whose
+// decoding gives the most letters of its languages and the fewest unexpected ones
+func detectCharset(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte(utf8BOM)), utf8.Valid(data):
+		return "UTF-8"
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return "UTF-16LE"
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return "UTF-16BE"
+	}
+
+	best, bestScore := legacyCharsets[0].name, 0
+	for i, cs := range legacyCharsets {
+		score := 0
+		for _, b := range data {
+			if b < 0x80 {
+				continue
+			}
+			r := cs.table[b-0x80]
+			if strings.ContainsRune(cs.letters, r) {
+				score++
+			} else if unicode.IsLetter(r) || r == utf8.RuneError {
+				score--
+			}
+		}
+		if i == 0 || score > bestScore {
+			best, bestScore = cs.name, score
+		}
+	}
+	return best
+}
+
+// subtitleCharsetArgs returns the --sub-charset option of an external text subtitle
+// that isn't Unicode, with a note for the run log. mkvmerge converts it to UTF-8 while
+// muxing; Unicode files need nothing, mkvmerge reads their BOM.
+func subtitleCharsetArgs(path string) ([]string, string, error) {
+	if !isTextSubtitleExt(filepath.Ext(path)) {
+		return nil, "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read subtitle: %v", err)
+	}
+
+	charset := detectCharset(data)
+	if strings.HasPrefix(charset, "UTF-") {
+		return nil, "", nil
+	}
+	note := fmt.Sprintf("🔠 CHARSET: %s converted from %s to UTF-8", filepath.Base(path), charset)
+	return []string{"--sub-charset", "0:" + charset}, note, nil
+}
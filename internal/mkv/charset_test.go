@@ -0,0 +1,93 @@
+package mkv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// encodeLegacy encodes text with the table of a legacy charset
+func encodeLegacy(t *testing.T, name, text string) []byte {
+	t.Helper()
+	for _, cs := range legacyCharsets {
+		if cs.name != name {
+			continue
+		}
+		var out []byte
+		for _, r := range text {
+			if r < 0x80 {
+				out = append(out, byte(r))
+				continue
+			}
+			i := slices.Index(cs.table[:], r)
+			if i < 0 {
+				t.Fatalf("%q has no %s encoding", r, name)
+			}
+			out = append(out, byte(0x80+i))
+		}
+		return out
+	}
+	t.Fatalf("unknown charset %s", name)
+	return nil
+}
+
+func TestDetectCharset(t *testing.T) {
+	italian := "1\n00:00:01,000 --> 00:00:02,000\nPerché è così? Non può andare in città, è già là.\n"
+	polish := "1\n00:00:01,000 --> 00:00:02,000\nZażółć gęślą jaźń, proszę się nie śmiać.\n"
+	czech := "1\n00:00:01,000 --> 00:00:02,000\nPříliš žluťoučký kůň úpěl ďábelské ódy.\n"
+	croatian := "1\n00:00:01,000 --> 00:00:02,000\nNeće on doći, što ćeš sad? Đački dom je zatvoren.\n"
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"utf-8", []byte(italian), "UTF-8"},
+		{"utf-8 bom", append([]byte(utf8BOM), polish...), "UTF-8"},
+		{"utf-16le bom", []byte{0xFF, 0xFE, '1', 0, '\n', 0, 0xE8, 0}, "UTF-16LE"},
+		{"utf-16be bom", []byte{0xFE, 0xFF, 0, '1', 0, '\n', 0, 0xE8}, "UTF-16BE"},
+		{"italian", encodeLegacy(t, "windows-1252", italian), "windows-1252"},
+		{"polish", encodeLegacy(t, "windows-1250", polish), "windows-1250"},
+		{"czech", encodeLegacy(t, "windows-1250", czech), "windows-1250"},
+		{"croatian", encodeLegacy(t, "windows-1250", croatian), "windows-1250"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectCharset(tt.data); got != tt.want {
+				t.Errorf("detectCharset = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubtitleCharsetArgs(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		return path
+	}
+
+	legacy := write("05_ita.srt", encodeLegacy(t, "windows-1252", "Così è la vita.\n"))
+	args, note, err := subtitleCharsetArgs(legacy)
+	if err != nil {
+		t.Fatalf("subtitleCharsetArgs failed: %v", err)
+	}
+	if want := []string{"--sub-charset", "0:windows-1252"}; !reflect.DeepEqual(args, want) || note == "" {
+		t.Errorf("subtitleCharsetArgs = %v, %q; want %v and a note", args, note, want)
+	}
+
+	for _, path := range []string{
+		write("05_eng.srt", []byte("Plain UTF-8 — fine.\n")),
+		write("05_ita.sup", []byte{0x50, 0x47, 0xE8, 0x00}), // Image subtitles are binary
+	} {
+		if args, note, err := subtitleCharsetArgs(path); err != nil || args != nil || note != "" {
+			t.Errorf("subtitleCharsetArgs(%s) = %v, %q, %v; want nothing", filepath.Base(path), args, note, err)
+		}
+	}
+}
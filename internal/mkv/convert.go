@@ -0,0 +1,302 @@
+package mkv
+
+import (
+	"fmt"
+	"mkvtea/internal/config"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// convertFormats maps the --convert formats to the extension of the converted file
+var convertFormats = map[string]string{
+	"srt": ".srt",
+	"vtt": ".vtt",
+	"ass": ".ass",
+}
+
+// ValidateConvertFormat checks a --convert value ("" disables conversion)
+func ValidateConvertFormat(format string) error {
+	if _, ok := convertFormats[format]; format != "" && !ok {
+		return fmt.Errorf("unknown format %q (use srt, vtt or ass)", format)
+	}
+	return nil
+}
+
+// subtitleCue is a subtitle event in milliseconds. Text uses SRT markup: lines
+// separated by "\n", with <i>, <b> and <u> tags.
+type subtitleCue struct {
+	start, end int64
+	text       string
+}
+
+var (
+	// 00:01:02,345 (SRT), 00:01:02.345 or 01:02.345 (WebVTT)
+	cueTimeRe = regexp.MustCompile(`^(?:(\d+):)?(\d{1,2}):(\d{2})[,.](\d{3})$`)
+	// 0:01:02.34 (ASS/SSA)
+	assTimeRe = regexp.MustCompile(`^(\d+):(\d{2}):(\d{2})\.(\d{2})$`)
+	// ASS override blocks and the tags inside them
+	assOverrideRe = regexp.MustCompile(`\{[^}]*\}`)
+	assTagRe      = regexp.MustCompile(`\\(i|b|u|p)(\d*)`)
+	// Markup tags other than the basic styles kept by every format
+	markupTagRe = regexp.MustCompile(`</?([a-zA-Z]+)[^>]*>`)
+)
+
+// convertSubtitle converts a text subtitle file to format, writing the result next to
+// it with the format's extension. It returns the path of the converted file.
+func convertSubtitle(path, format string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	target := convertFormats[format]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var cues []subtitleCue
+	switch ext {
+	case ".srt", ".vtt":
+		cues = parseSRT(string(data))
+	case ".ass", ".ssa":
+		cues = parseASS(string(data))
+	default:
+		return "", fmt.Errorf("cannot convert %s subtitles", ext)
+	}
+
+	var out string
+	switch format {
+	case "srt":
+		out = writeSRT(cues)
+	case "vtt":
+		out = writeVTT(cues)
+	case "ass":
+		out = writeASS(cues)
+	}
+
+	converted := strings.TrimSuffix(path, filepath.Ext(path)) + target
+	if err := os.WriteFile(converted, []byte(out), 0644); err != nil {
+		return "", err
+	}
+	return converted, nil
+}
+
+// convertExtracted converts an extracted subtitle to cfg.Convert, giving the converted
+// file its own sidecar and removing the original when cfg.ConvertReplace is set.
+// Image-based tracks are kept as they are, with a note.
+func convertExtracted(job extractJob, cfg config.Config) (string, error) {
+	ext := strings.ToLower(filepath.Ext(job.output))
+	if ext == convertFormats[cfg.Convert] || (ext == ".ssa" && cfg.Convert == "ass") {
+		return "", nil
+	}
+	if !isTextSubtitleExt(ext) {
+		return fmt.Sprintf("⚠️ CONVERT: %s is not a text subtitle, kept as %s", filepath.Base(job.output), ext), nil
+	}
+
+	converted, err := convertSubtitle(job.output, cfg.Convert)
+	if err != nil {
+		return "", err
+	}
+	if err := writeSidecar(converted, job.track, job.lang); err != nil {
+		return "", err
+	}
+	if cfg.ConvertReplace {
+		os.Remove(job.output)
+		os.Remove(job.output + sidecarExt)
+	}
+	return "", nil
+}
+
+// parseSRT reads SRT and WebVTT cues; WebVTT headers, notes and styles are skipped
+func parseSRT(data string) []subtitleCue {
+	data = strings.TrimPrefix(strings.ReplaceAll(data, "\r\n", "\n"), utf8BOM)
+	var cues []subtitleCue
+	for _, block := range strings.Split(data, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		for i, line := range lines {
+			if !strings.Contains(line, "-->") {
+				continue
+			}
+			parts := strings.Fields(strings.Replace(line, "-->", " --> ", 1))
+			if len(parts) < 3 {
+				break
+			}
+			start, ok1 := parseCueTime(parts[0])
+			end, ok2 := parseCueTime(parts[2])
+			if ok1 && ok2 {
+				text := strings.Join(lines[i+1:], "\n")
+				cues = append(cues, subtitleCue{start: start, end: end, text: keepBasicMarkup(text)})
+			}
+			break
+		}
+	}
+	return cues
+}
+
+func parseCueTime(s string) (int64, bool) {
+	m := cueTimeRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	h, _ := strconv.ParseInt(m[1], 10, 64)
+	minutes, _ := strconv.ParseInt(m[2], 10, 64)
+	sec, _ := strconv.ParseInt(m[3], 10, 64)
+	ms, _ := strconv.ParseInt(m[4], 10, 64)
+	return ((h*60+minutes)*60+sec)*1000 + ms, true
+}
+
+// parseASS reads the Dialogue events of an ASS/SSA script, using the [Events] format
+// line to locate the fields. Drawings are dropped and override tags mapped to markup.
+func parseASS(data string) []subtitleCue {
+	data = strings.TrimPrefix(strings.ReplaceAll(data, "\r\n", "\n"), utf8BOM)
+	format := strings.Split(strings.TrimPrefix(defaultASSFormat, "Format:"), ",")
+	inEvents := false
+
+	var cues []subtitleCue
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "["):
+			inEvents = strings.EqualFold(line, "[Events]")
+		case inEvents && strings.HasPrefix(line, "Format:"):
+			format = strings.Split(strings.TrimPrefix(line, "Format:"), ",")
+		case inEvents && strings.HasPrefix(line, "Dialogue:"):
+			fields := strings.SplitN(strings.TrimPrefix(line, "Dialogue:"), ",", len(format))
+			if len(fields) != len(format) {
+				continue
+			}
+			var start, end int64
+			var text string
+			var ok1, ok2 bool
+			for i, name := range format {
+				value := strings.TrimSpace(fields[i])
+				switch strings.TrimSpace(name) {
+				case "Start":
+					start, ok1 = parseASSTime(value)
+				case "End":
+					end, ok2 = parseASSTime(value)
+				case "Text":
+					text = fields[i]
+				}
+			}
+			if !ok1 || !ok2 {
+				continue
+			}
+			if text, ok := assToMarkup(text); ok && strings.TrimSpace(text) != "" {
+				cues = append(cues, subtitleCue{start: start, end: end, text: text})
+			}
+		}
+	}
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].start < cues[j].start })
+	return cues
+}
+
+func parseASSTime(s string) (int64, bool) {
+	m := assTimeRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	h, _ := strconv.ParseInt(m[1], 10, 64)
+	minutes, _ := strconv.ParseInt(m[2], 10, 64)
+	sec, _ := strconv.ParseInt(m[3], 10, 64)
+	cs, _ := strconv.ParseInt(m[4], 10, 64)
+	return ((h*60+minutes)*60+sec)*1000 + cs*10, true
+}
+
+// assToMarkup strips ASS override tags, mapping italic, bold and underline to markup.
+// The boolean is false for drawings (\p1), which have no text equivalent.
+func assToMarkup(text string) (string, bool) {
+	open := map[string]bool{}
+	drawing := false
+	var sb strings.Builder
+	last := 0
+	for _, loc := range assOverrideRe.FindAllStringIndex(text, -1) {
+		sb.WriteString(text[last:loc[0]])
+		last = loc[1]
+		for _, m := range assTagRe.FindAllStringSubmatch(text[loc[0]:loc[1]], -1) {
+			tag, on := m[1], m[2] != "" && m[2] != "0"
+			if tag == "p" {
+				drawing = on
+				continue
+			}
+			if m[2] == "" {
+				on = false // \i alone resets to the style default
+			}
+			if on != open[tag] {
+				open[tag] = on
+				if on {
+					sb.WriteString("<" + tag + ">")
+				} else {
+					sb.WriteString("</" + tag + ">")
+				}
+			}
+		}
+	}
+	sb.WriteString(text[last:])
+	if drawing {
+		return "", false
+	}
+	for _, tag := range []string{"u", "b", "i"} {
+		if open[tag] {
+			sb.WriteString("</" + tag + ">")
+		}
+	}
+
+	out := strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(sb.String())
+	return out, true
+}
+
+// keepBasicMarkup removes tags other than <i>, <b> and <u> (fonts, WebVTT voices and classes)
+func keepBasicMarkup(text string) string {
+	return markupTagRe.ReplaceAllStringFunc(text, func(tag string) string {
+		switch strings.ToLower(markupTagRe.FindStringSubmatch(tag)[1]) {
+		case "i", "b", "u":
+			return strings.ToLower(tag)
+		}
+		return ""
+	})
+}
+
+func writeSRT(cues []subtitleCue) string {
+	var sb strings.Builder
+	for i, c := range cues {
+		fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1,
+			formatCueTime(c.start*1000000, ','), formatCueTime(c.end*1000000, ','), c.text)
+	}
+	return sb.String()
+}
+
+func writeVTT(cues []subtitleCue) string {
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n\n")
+	for _, c := range cues {
+		fmt.Fprintf(&sb, "%s --> %s\n%s\n\n",
+			formatCueTime(c.start*1000000, '.'), formatCueTime(c.end*1000000, '.'), c.text)
+	}
+	return sb.String()
+}
+
+// assHeader is the script header of converted ASS files
+const assHeader = "[Script Info]\nScriptType: v4.00+\nPlayResX: 1920\nPlayResY: 1080\n\n" +
+	"[V4+ Styles]\n" +
+	"Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n" +
+	"Style: Default,Arial,72,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,3,1,2,60,60,50,1\n\n" +
+	"[Events]\n" + defaultASSFormat + "\n"
+
+var markupToASS = strings.NewReplacer(
+	"<i>", `{\i1}`, "</i>", `{\i0}`,
+	"<b>", `{\b1}`, "</b>", `{\b0}`,
+	"<u>", `{\u1}`, "</u>", `{\u0}`,
+	"\n", `\N`,
+)
+
+func writeASS(cues []subtitleCue) string {
+	var sb strings.Builder
+	sb.WriteString(assHeader)
+	for _, c := range cues {
+		fmt.Fprintf(&sb, "Dialogue: 0,%s,%s,Default,,0,0,0,,%s\n",
+			formatASSTime(c.start*1000000), formatASSTime(c.end*1000000), markupToASS.Replace(c.text))
+	}
+	return sb.String()
+}
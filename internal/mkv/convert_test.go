@@ -0,0 +1,97 @@
+package mkv
+
+import (
+	"mkvtea/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const sampleASS = "\xEF\xBB\xBF[Script Info]\nScriptType: v4.00+\n\n[Events]\n" +
+	"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+	"Dialogue: 0,0:00:03.50,0:00:05.00,Default,,0,0,0,,{\\an8\\b1}Second{\\b0}, with a comma\n" +
+	"Dialogue: 0,0:00:01.00,0:00:02.25,Default,,0,0,0,,{\\i1}First\\Nline\n" +
+	"Dialogue: 0,0:00:01.00,0:00:09.00,Sign,,0,0,0,,{\\p1}m 0 0 l 100 0 100 100\n"
+
+func TestParseASS(t *testing.T) {
+	cues := parseASS(sampleASS)
+	want := []subtitleCue{
+		{start: 1000, end: 2250, text: "<i>First\nline</i>"},
+		{start: 3500, end: 5000, text: "<b>Second</b>, with a comma"},
+	}
+	if len(cues) != len(want) {
+		t.Fatalf("parseASS returned %d cues; want %d: %+v", len(cues), len(want), cues)
+	}
+	for i := range want {
+		if cues[i] != want[i] {
+			t.Errorf("cue %d = %+v; want %+v", i, cues[i], want[i])
+		}
+	}
+}
+
+func TestParseSRT(t *testing.T) {
+	vtt := "WEBVTT\n\nNOTE generated\n\nintro\n00:01.000 --> 00:02.500 align:start\n<v Mario><i>Ciao</i> <font color=\"red\">a tutti</font>\n\n"
+	cues := parseSRT(vtt)
+	if len(cues) != 1 || cues[0] != (subtitleCue{start: 1000, end: 2500, text: "<i>Ciao</i> a tutti"}) {
+		t.Errorf("parseSRT(vtt) = %+v", cues)
+	}
+
+	srt := "1\r\n00:00:01,000 --> 00:00:02,500\r\nCiao\r\n\r\n2\r\n01:00:00,000 --> 01:00:01,000\r\nAddio\r\n"
+	if cues := parseSRT(srt); len(cues) != 2 || cues[1].start != 3600000 {
+		t.Errorf("parseSRT(srt) = %+v", cues)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	cues := []subtitleCue{{start: 1000, end: 2250, text: "<i>First\nline</i>"}}
+
+	if got := writeSRT(cues); got != "1\n00:00:01,000 --> 00:00:02,250\n<i>First\nline</i>\n\n" {
+		t.Errorf("writeSRT = %q", got)
+	}
+	if got := writeVTT(cues); got != "WEBVTT\n\n00:00:01.000 --> 00:00:02.250\n<i>First\nline</i>\n\n" {
+		t.Errorf("writeVTT = %q", got)
+	}
+	got := writeASS(cues)
+	want := assHeader + "Dialogue: 0,0:00:01.00,0:00:02.25,Default,,0,0,0,,{\\i1}First\\Nline{\\i0}\n"
+	if got != want {
+		t.Errorf("writeASS = %q", got)
+	}
+}
+
+func TestConvertExtracted(t *testing.T) {
+	dir := t.TempDir()
+	ass := filepath.Join(dir, "05_ita.ass")
+	if err := os.WriteFile(ass, []byte(sampleASS), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	job := extractJob{track: Track{ID: 2, Type: "subtitles"}, kind: "subtitle", output: ass, lang: "ita"}
+
+	cfg := config.Config{Convert: "srt"}
+	if _, err := convertExtracted(job, cfg); err != nil {
+		t.Fatalf("convertExtracted failed: %v", err)
+	}
+	for _, name := range []string{"05_ita.ass", "05_ita.srt", "05_ita.srt.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to exist: %v", name, err)
+		}
+	}
+
+	cfg = config.Config{Convert: "vtt", ConvertReplace: true}
+	if _, err := convertExtracted(job, cfg); err != nil {
+		t.Fatalf("convertExtracted failed: %v", err)
+	}
+	if _, err := os.Stat(ass); !os.IsNotExist(err) {
+		t.Errorf("original subtitle should have been replaced")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "05_ita.vtt")); err != nil {
+		t.Errorf("expected the converted subtitle: %v", err)
+	}
+
+	pgs := extractJob{track: Track{ID: 3}, kind: "subtitle", output: filepath.Join(dir, "05_ita.sup")}
+	if note, err := convertExtracted(pgs, cfg); err != nil || note == "" {
+		t.Errorf("convertExtracted(PGS) = %q, %v; want a note", note, err)
+	}
+	if err := ValidateConvertFormat("sub"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,208 @@
+package mkv
+
+import (
+	"fmt"
+	"mkvtea/internal/config"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// versionRe matches a release version right after the episode key, e.g. "v2" in 05v2_ita.ass
+var versionRe = regexp.MustCompile(`(?i)^v(\d+)`)
+
+// DiscoverAssets finds the external subtitles, audio and fonts that belong to a video
+// using the automatic heuristics (episode number in the file name, language code, or
+// name similarity depending on cfg.Match). Notes describe fuzzy pairing decisions.
+func DiscoverAssets(path string, cfg config.Config) (PairingEntry, []string) {
+	epKeys := episodeKeys(filepath.Base(path), cfg)
+	rel, _ := ParseRelease(filepath.Base(path), cachedEpisodePattern(cfg.EpisodePattern))
+	entry := PairingEntry{Video: path}
+	var notes []string
+
+	// Unnumbered videos (movies, specials) fall back to name similarity when no file
+	// carries their XX prefix
+	fuzzy := cfg.Match == "fuzzy" || (cfg.Match != "episode" && epKeys[0] == "XX")
+	find := func(dir string, acceptExt func(ext string) bool) string {
+		if cfg.Match != "fuzzy" {
+			var file string
+			var ignored []string
+			if cfg.NameTemplate != "" {
+				file, ignored = findTemplateFile(dir, cfg.NameTemplate, rel, cfg.Lang, acceptExt)
+			} else {
+				file, ignored = findEpisodeFile(dir, epKeys, cfg.Lang, acceptExt)
+			}
+			if len(ignored) > 0 {
+				notes = append(notes, fmt.Sprintf("⚠️ MULTIPLE MATCHES: %s → using %s, ignored %s",
+					filepath.Base(path), filepath.Base(file), strings.Join(ignored, ", ")))
+			}
+			if file != "" || !fuzzy {
+				return file
+			}
+		}
+		file, note := findSimilarFile(path, dir, acceptExt, cfg)
+		if note != "" {
+			notes = append(notes, note)
+		}
+		return file
+	}
+
+	subsSource := cfg.SubsDir
+	if subsSource == "" {
+		subsSource = filepath.Join(filepath.Dir(path), "subs", cfg.Lang)
+	}
+
+	audioSource := cfg.AudioDir
+	if audioSource == "" {
+		audioSource = subsSource
+	}
+
+	// Search for subtitles
+	if subFile := find(subsSource, isSubtitleExt); subFile != "" {
+		entry.Subtitles = []string{subFile}
+	}
+
+	// Search for audio
+	if cfg.Audio {
+		if audioFile := find(audioSource, isAudioExt); audioFile != "" {
+			entry.Audio = []string{audioFile}
+		}
+	}
+
+	// Chapters matched by episode, or by video name for unnumbered videos
+	switch {
+	case cfg.ChaptersDir == "":
+	case epKeys[0] == "XX":
+		entry.Chapters = findVideoChapters(cfg.ChaptersDir, path)
+	case cfg.Match != "fuzzy":
+		entry.Chapters, _ = findEpisodeFile(cfg.ChaptersDir, epKeys, "", isChapterExt)
+	}
+
+	// Fonts stored next to the subtitles, or in the shared folder written by extract
+	fontDirs := []string{subsSource, filepath.Join(subsSource, "fonts")}
+	if cfg.SubsDir == "" {
+		fontDirs = append(fontDirs, filepath.Join(filepath.Dir(path), "subs", "fonts"))
+	}
+	entry.Fonts = findFonts(fontDirs...)
+
+	return entry, notes
+}
+
+// findEpisodeFile searches dir for a file of the given language belonging to the episode.
+// Keys are tried in order, so files named after the full episode identity (S01E05_ita.ass)
+// win over legacy two-digit names (05_ita.ass). When several files match the same key,
+// the main track is chosen (05_ita_2.ass over 05_ita_forced.ass), then among its versions
+// the highest release version (05v2_ita.ass) wins, then the most recently modified file;
+// the names of the other candidates are returned so they can be reported.
+func findEpisodeFile(dir string, keys []string, lang string, acceptExt func(ext string) bool) (string, []string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil
+	}
+
+	for _, key := range keys {
+		var candidates []episodeCandidate
+		for _, f := range entries {
+			if f.IsDir() || !hasEpisodePrefix(f.Name(), key) || !strings.Contains(f.Name(), lang) {
+				continue
+			}
+			if !acceptExt(strings.ToLower(filepath.Ext(f.Name()))) {
+				continue
+			}
+			rest := f.Name()[len(key):]
+			c := episodeCandidate{name: f.Name(), version: fileVersion(rest), variant: fileVariant(rest)}
+			if fi, err := f.Info(); err == nil {
+				c.modTime = fi.ModTime()
+			}
+			candidates = append(candidates, c)
+		}
+		if len(candidates) > 0 {
+			return pickCandidate(dir, candidates)
+		}
+	}
+	return "", nil
+}
+
+// pickCandidate chooses the main track of the episode, then its highest version and
+// most recently modified file, and returns the names of the other candidates. Versions
+// only compete with each other: a newer forced track never replaces the full one.
+func pickCandidate(dir string, candidates []episodeCandidate) (string, []string) {
+	main := candidates[0].variant
+	for _, c := range candidates[1:] {
+		if isForcedVariant(main) != isForcedVariant(c.variant) {
+			if isForcedVariant(main) {
+				main = c.variant
+			}
+		} else if c.variant < main {
+			main = c.variant
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if (a.variant == main) != (b.variant == main) {
+			return a.variant == main
+		}
+		if a.variant != main {
+			return a.name < b.name
+		}
+		if a.version != b.version {
+			return a.version > b.version
+		}
+		if !a.modTime.Equal(b.modTime) {
+			return a.modTime.After(b.modTime)
+		}
+		return a.name < b.name
+	})
+
+	var ignored []string
+	for _, c := range candidates[1:] {
+		ignored = append(ignored, c.name)
+	}
+	return filepath.Join(dir, candidates[0].name), ignored
+}
+
+type episodeCandidate struct {
+	name    string
+	version int
+	variant string // Track within the episode ("_ita_forced"); equal for versions of one file
+	modTime time.Time
+}
+
+// isForcedVariant reports whether a candidate holds a forced or signs track
+func isForcedVariant(variant string) bool {
+	variant = strings.ToLower(variant)
+	return strings.Contains(variant, "forced") || strings.Contains(variant, "sign")
+}
+
+// fileVersion reads the release version that follows the episode key ("v2_ita.ass" → 2).
+// Files without a version count as version 1.
+func fileVersion(rest string) int {
+	if m := versionRe.FindStringSubmatch(rest); m != nil {
+		return atoi(m[1])
+	}
+	return 1
+}
+
+// fileVariant strips the release version and extension from what follows the episode
+// key ("v2_ita_forced.ass" → "_ita_forced")
+func fileVariant(rest string) string {
+	rest = strings.TrimSuffix(rest, filepath.Ext(rest))
+	if loc := versionRe.FindStringIndex(rest); loc != nil {
+		rest = rest[loc[1]:]
+	}
+	return rest
+}
+
+// subtitleExts lists the external subtitle formats accepted by merge
+// (.idx stands for a VobSub .idx/.sub pair)
+var subtitleExts = map[string]bool{
+	".srt": true, ".ass": true, ".ssa": true, ".vtt": true, ".usf": true, ".sup": true, ".idx": true,
+}
+
+func isSubtitleExt(ext string) bool {
+	return subtitleExts[strings.ToLower(ext)]
+}
@@ -0,0 +1,97 @@
+package mkv
+
+import (
+	"mkvtea/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindEpisodeFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"05_ita.ass", "S02E05_ita.ass", "S01E06_ita.srt", "05_ita.xml", "01_ita.ass", "03-04_ita.ass"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	isSub := func(ext string) bool { return ext == ".srt" || ext == ".ass" }
+
+	tests := []struct {
+		video    string
+		expected string
+	}{
+		{"Show S02E05.mkv", "S02E05_ita.ass"},
+		{"Show S01E05.mkv", "05_ita.ass"}, // legacy fallback
+		{"Show S01E06.mkv", "S01E06_ita.srt"},
+		{"Show S01E07.mkv", ""},
+		{"Show - 01-02.mkv", "01_ita.ass"}, // first episode of the range
+		{"Show - 03-04.mkv", "03-04_ita.ass"},
+		{"Show - 03.mkv", ""},
+	}
+
+	for _, tt := range tests {
+		got, _ := findEpisodeFile(dir, episodeKeys(tt.video, config.Config{}), "ita", isSub)
+		if tt.expected == "" {
+			if got != "" {
+				t.Errorf("findEpisodeFile(%q) = %q; want no match", tt.video, got)
+			}
+			continue
+		}
+		if filepath.Base(got) != tt.expected {
+			t.Errorf("findEpisodeFile(%q) = %q; want %q", tt.video, got, tt.expected)
+		}
+	}
+}
+
+func TestFindEpisodeFilePrefersNewestVersion(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	files := []struct {
+		name string
+		age  time.Duration
+	}{
+		{"05_ita.ass", 0},
+		{"05v2_ita.ass", 2 * time.Hour},
+		{"05v3_ita.srt", 3 * time.Hour},
+		{"06_ita.ass", 2 * time.Hour},
+		{"06_ita.srt", time.Hour},
+		{"07_ita_2.ass", 2 * time.Hour},
+		{"07_ita_forced.ass", time.Hour},
+		{"07v2_ita_2.ass", 3 * time.Hour},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := os.Chtimes(path, now.Add(-f.age), now.Add(-f.age)); err != nil {
+			t.Fatalf("Failed to set file time: %v", err)
+		}
+	}
+
+	// Highest version wins regardless of modification time
+	got, ignored := findEpisodeFile(dir, []string{"05"}, "ita", isSubtitleExt)
+	if filepath.Base(got) != "05v3_ita.srt" {
+		t.Errorf("expected 05v3_ita.srt, got %q", got)
+	}
+	if strings.Join(ignored, ",") != "05v2_ita.ass,05_ita.ass" {
+		t.Errorf("unexpected ignored candidates: %v", ignored)
+	}
+
+	// Same version: newest modification time wins
+	got, ignored = findEpisodeFile(dir, []string{"06"}, "ita", isSubtitleExt)
+	if filepath.Base(got) != "06_ita.srt" || len(ignored) != 1 {
+		t.Errorf("expected 06_ita.srt with one ignored file, got %q %v", got, ignored)
+	}
+
+	// Versions only compete with each other: a newer forced track never replaces the full one
+	got, ignored = findEpisodeFile(dir, []string{"07"}, "ita", isSubtitleExt)
+	if filepath.Base(got) != "07v2_ita_2.ass" {
+		t.Errorf("expected 07v2_ita_2.ass, got %q", got)
+	}
+	if strings.Join(ignored, ",") != "07_ita_2.ass,07_ita_forced.ass" {
+		t.Errorf("unexpected ignored candidates: %v", ignored)
+	}
+}
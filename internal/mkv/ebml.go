@@ -0,0 +1,201 @@
+package mkv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// unknownSize marks a master element whose size is not written (live streams,
+// segments being muxed)
+const unknownSize = -1
+
+// maxElementSize bounds the elements read into memory, so that a corrupted size
+// cannot make the reader allocate gigabytes
+const maxElementSize = 64 << 20
+
+var errInvalidVint = errors.New("invalid EBML variable-size integer")
+
+// ebmlReader reads EBML element headers and bodies from a seekable file
+type ebmlReader struct {
+	r   io.ReadSeeker
+	pos int64
+}
+
+// ebmlElement is an element header: its ID, body size and body offset in the file
+type ebmlElement struct {
+	id     uint32
+	size   int64
+	offset int64
+}
+
+func (e ebmlElement) end() int64 {
+	return e.offset + e.size
+}
+
+// readVint reads a variable-size integer. IDs keep their length marker; sizes drop it,
+// and a size with all value bits set means "unknown".
+func (e *ebmlReader) readVint(keepMarker bool) (uint64, int, error) {
+	var first [1]byte
+	if _, err := io.ReadFull(e.r, first[:]); err != nil {
+		return 0, 0, err
+	}
+	e.pos++
+
+	length := 1
+	for mask := byte(0x80); first[0]&mask == 0; mask >>= 1 {
+		length++
+		if length > 8 {
+			return 0, 0, errInvalidVint
+		}
+	}
+
+	value := uint64(first[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	rest := make([]byte, length-1)
+	if _, err := io.ReadFull(e.r, rest); err != nil {
+		return 0, 0, err
+	}
+	e.pos += int64(length - 1)
+	for _, b := range rest {
+		value = value<<8 | uint64(b)
+	}
+	return value, length, nil
+}
+
+// next reads the header of the element at the current position
+func (e *ebmlReader) next() (ebmlElement, error) {
+	id, idLen, err := e.readVint(true)
+	if err != nil {
+		return ebmlElement{}, err
+	}
+	if idLen > 4 {
+		return ebmlElement{}, fmt.Errorf("invalid EBML element ID at offset %d", e.pos-int64(idLen))
+	}
+	size, sizeLen, err := e.readVint(false)
+	if err != nil {
+		return ebmlElement{}, err
+	}
+	el := ebmlElement{id: uint32(id), size: int64(size), offset: e.pos}
+	if size == 1<<(7*sizeLen)-1 {
+		el.size = unknownSize
+	}
+	return el, nil
+}
+
+// seek moves to an absolute offset in the file
+func (e *ebmlReader) seek(offset int64) error {
+	if _, err := e.r.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	e.pos = offset
+	return nil
+}
+
+// skip moves past the body of an element
+func (e *ebmlReader) skip(el ebmlElement) error {
+	if el.size == unknownSize {
+		return fmt.Errorf("cannot skip element %X of unknown size", el.id)
+	}
+	return e.seek(el.end())
+}
+
+// body reads the whole body of an element into memory
+func (e *ebmlReader) body(el ebmlElement) ([]byte, error) {
+	if el.size == unknownSize || el.size > maxElementSize {
+		return nil, fmt.Errorf("element %X too large to read (%d bytes)", el.id, el.size)
+	}
+	if err := e.seek(el.offset); err != nil {
+		return nil, err
+	}
+	data := make([]byte, el.size)
+	if _, err := io.ReadFull(e.r, data); err != nil {
+		return nil, err
+	}
+	e.pos += el.size
+	return data, nil
+}
+
+// children calls fn for each child of a master element; fn may read the child's body,
+// the reader is moved past it afterwards either way
+func (e *ebmlReader) children(parent ebmlElement, fn func(el ebmlElement) error) error {
+	if err := e.seek(parent.offset); err != nil {
+		return err
+	}
+	for parent.size == unknownSize || e.pos < parent.end() {
+		el, err := e.next()
+		if err == io.EOF && parent.size == unknownSize {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if el.size == unknownSize {
+			return fmt.Errorf("element %X of unknown size inside %X", el.id, parent.id)
+		}
+		if err := fn(el); err != nil {
+			return err
+		}
+		if err := e.skip(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ebmlNode is an element parsed from memory
+type ebmlNode struct {
+	id   uint32
+	data []byte
+}
+
+// parseChildren splits the body of a master element read into memory
+func parseChildren(data []byte) ([]ebmlNode, error) {
+	var nodes []ebmlNode
+	r := &ebmlReader{r: bytes.NewReader(data)}
+	for r.pos < int64(len(data)) {
+		el, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		if el.size == unknownSize || el.end() > int64(len(data)) {
+			return nil, fmt.Errorf("element %X overflows its parent", el.id)
+		}
+		nodes = append(nodes, ebmlNode{id: el.id, data: data[el.offset:el.end()]})
+		if err := r.skip(el); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// ebmlUint decodes a big-endian unsigned integer of up to eight bytes
+func ebmlUint(data []byte) uint64 {
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// ebmlFloat decodes a four- or eight-byte IEEE float; other lengths read as zero
+func ebmlFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	}
+	return 0
+}
+
+// ebmlString decodes an ASCII or UTF-8 string, dropping the optional NUL padding
+func ebmlString(data []byte) string {
+	return strings.TrimRight(string(data), "\x00")
+}
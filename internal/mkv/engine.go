@@ -1,60 +1,110 @@
 package mkv
 
 import (
+	"errors"
 	"fmt"
 	"mkvtea/internal/config"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
-// ValidateDependencies checks if required MKV tools are installed
-func ValidateDependencies() error {
-	tools := []string{"mkvmerge", "mkvextract", "mkvpropedit"}
-	var missingTools []string
+// requiredTools lists the MKVToolNix programs used by mkvtea
+var requiredTools = []string{"mkvmerge", "mkvextract", "mkvpropedit"}
 
-	for _, tool := range tools {
-		_, err := exec.LookPath(tool)
-		if err != nil {
+// MissingTools returns the MKVToolNix programs that are not in the PATH
+func MissingTools() []string {
+	var missingTools []string
+	for _, tool := range requiredTools {
+		if _, err := exec.LookPath(tool); err != nil {
 			missingTools = append(missingTools, tool)
 		}
 	}
+	return missingTools
+}
 
-	if len(missingTools) > 0 {
-		return fmt.Errorf(`❌ Missing required MKV tools: %s
+// ValidateDependencies checks if the MKV tools required by mode are installed.
+// Extract needs none up front: Matroska metadata and text subtitles are read natively,
+// and files that need a missing tool fail on their own.
+func ValidateDependencies(mode string) error {
+	missingTools := MissingTools()
+	if mode == "extract" || len(missingTools) == 0 {
+		return nil
+	}
+	return fmt.Errorf(`❌ Missing required MKV tools: %s
+%s
+Ensure they are in your PATH and try again`, strings.Join(missingTools, ", "), installHelp)
+}
 
+// installHelp explains how to install MKVToolNix
+const installHelp = `
 These tools are part of MKVToolNix suite. Install with:
 
   macOS:  brew install mkvtoolnix
   Ubuntu: sudo apt install mkvtoolnix
   Fedora: sudo dnf install mkvtoolnix
   Arch:   sudo pacman -S mkvtoolnix-cli
+`
 
-Ensure they are in your PATH and try again`, strings.Join(missingTools, ", "))
-	}
-
-	return nil
-}
-
-// execute runs a command
+// execute runs a command, reporting the tool's own error message when it fails
 func execute(command string, args ...string) error {
 	cmd := exec.Command(command, args...)
-	if err := cmd.Run(); err != nil {
+	out, err := cmd.CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%s not found: install MKVToolNix to process this file", command)
+	}
+	if err != nil {
+		if msg := toolError(out); msg != "" {
+			return fmt.Errorf("%s command failed: %s", command, msg)
+		}
 		return fmt.Errorf("%s command failed: %v", command, err)
 	}
 	return nil
 }
 
-// RunExtract extracts subtitles from an MKV file based on the configured language(s)
-func RunExtract(path string, cfg config.Config) error {
-	info, err := GetInfo(path)
+// toolError returns the last "Error:" line printed by an MKVToolNix program
+func toolError(output []byte) string {
+	var msg string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Error:") {
+			msg = strings.TrimSpace(strings.TrimPrefix(line, "Error:"))
+		}
+	}
+	return msg
+}
+
+// Result carries informational messages produced while processing a file,
+// shown in the TUI log next to the file's status line
+type Result struct {
+	Notes     []string
+	Languages []string // Languages of the tracks extracted from the file
+}
+
+// RunExtract extracts subtitles from an MKV file based on the configured language(s).
+// All selected tracks, fonts and chapters are written by a single mkvextract call.
+func RunExtract(path string, cfg config.Config) (Result, error) {
+	// MP4 tracks are copied into a temporary MKV first; outputs keep the MP4's name
+	source := path
+	if isMP4(path) {
+		tmp, err := remuxToMKV(path)
+		if err != nil {
+			return Result{}, err
+		}
+		defer os.Remove(tmp)
+		source = tmp
+	}
+
+	info, err := GetInfo(source)
 	if err != nil {
-		return err
+		return Result{}, err
 	}
 
-	epNum := GetEpisodeNumber(filepath.Base(path))
-	overallFound := false
+	epNum := episodeKeys(filepath.Base(path), cfg)[0]
+	nameFields := episodeNameFields(ParseRelease(filepath.Base(path), cachedEpisodePattern(cfg.EpisodePattern)))
+	var batch extractBatch
 
 	// If no languages specified, use the main Lang field
 	languages := cfg.Languages
@@ -62,119 +112,262 @@ func RunExtract(path string, cfg config.Config) error {
 		languages = []string{cfg.Lang}
 	}
 
-	// Extract subtitles for each requested language
+	// "all" extracts every track into a folder named after its own language;
+	// undefined tracks then simply go to "und"
+	all := len(languages) == 1 && languages[0] == AllLanguages
+	matches := trackMatchesLanguage
+	undTarget := ""
+	if all {
+		languages = fileLanguages(info.Tracks, cfg.Audio)
+		matches = func(t Track, lang string) bool { return trackLanguage(t) == lang }
+	} else {
+		// Undefined subtitle tracks go to a single language, chosen by the --undefined policy
+		undTarget = undefinedTarget(cfg.Undefined, languages)
+	}
+	var guesses []undefinedGuess
+	var notes []string // Track renames, reported once the batch succeeded
+
+	// Select subtitles for each requested language
 	for _, lang := range languages {
 		subsDir := filepath.Join(filepath.Dir(path), "subs", lang)
 		if err := os.MkdirAll(subsDir, os.ModePerm); err != nil {
-			return fmt.Errorf("failed to create subtitle directory: %v", err)
+			return Result{}, fmt.Errorf("failed to create subtitle directory: %v", err)
 		}
 
 		for i, t := range info.Tracks {
-			if t.Type == "subtitles" && (t.Props.Lang == lang || t.Props.Lang == "und") {
-				overallFound = true
-
-				ext := ".srt"
-				// Detect ASS format
-				if strings.Contains(strings.ToLower(t.Codec), "ass") || strings.Contains(strings.ToLower(t.Codec), "substationalpha") {
-					ext = ".ass"
-				}
-				// Handle forced/sign subtitle tracks
-				suffix := ""
-				if strings.Contains(strings.ToLower(t.Props.TrackName), "sign") || t.Props.Forced {
-					suffix = "_forced"
-				} else if i > 0 {
-					suffix = fmt.Sprintf("_%d", i)
+			if t.Type == "subtitles" && (matches(t, lang) || (t.Props.Lang == undefinedLang && undTarget == lang)) {
+				outName := subtitleFileName(epNum, nameFields, i, t, lang, cfg)
+				ext := getSubtitleExtension(t.Codec, t.Props.CodecID)
+
+				// Text tracks are extracted aside and moved once their language is known
+				if !all && t.Props.Lang == undefinedLang && cfg.Undefined == "guess" && isTextSubtitleExt(ext) {
+					tmp := filepath.Join(filepath.Dir(path), "subs", fmt.Sprintf(".%s.%d.und.tmp", filepath.Base(path), t.ID))
+					guesses = append(guesses, undefinedGuess{index: i, track: t, tmpPath: tmp, ext: ext})
+					batch.addTrack(t, "subtitle", tmp, "")
+					continue
 				}
-
-				outName := fmt.Sprintf("%s_%s%s%s", epNum, lang, suffix, ext)
-				outputPath := filepath.Join(subsDir, outName)
-				if err := execute("mkvextract", path, "tracks", fmt.Sprintf("%d:%s", t.ID, outputPath)); err != nil {
-					return fmt.Errorf("subtitle extraction failed: %v", err)
+				if out := batch.addTrack(t, "subtitle", filepath.Join(subsDir, outName), lang); filepath.Base(out) != outName {
+					notes = append(notes, nameClashNote(t, out))
 				}
 			}
 
-			// Extract audio if requested and matches language
-			if cfg.Audio && t.Type == "audio" && t.Props.Lang == lang {
-				overallFound = true
+			// Select audio if requested and matches language
+			if cfg.Audio && t.Type == "audio" && matches(t, lang) {
 				ext := getAudioExtension(t.Codec)
 				suffix := ""
 				if i > 0 {
 					suffix = fmt.Sprintf("_%d", i)
 				}
 				outName := fmt.Sprintf("%s_%s%s%s", epNum, lang, suffix, ext)
-				outputPath := filepath.Join(subsDir, outName)
-				if err := execute("mkvextract", path, "tracks", fmt.Sprintf("%d:%s", t.ID, outputPath)); err != nil {
-					return fmt.Errorf("audio extraction failed: %v", err)
+				if cfg.NameTemplate != "" {
+					outName = RenderName(cfg.NameTemplate, trackNameFields(nameFields, t, lang, false, ext))
+				}
+				if out := batch.addTrack(t, "audio", filepath.Join(subsDir, outName), lang); filepath.Base(out) != outName {
+					notes = append(notes, nameClashNote(t, out))
 				}
 			}
 		}
 	}
 
-	if !overallFound {
-		return fmt.Errorf("skipped")
+	// Select chapters if requested
+	if cfg.Chapters != "" && len(info.Chapters) > 0 {
+		chaptersDir := filepath.Join(filepath.Dir(path), "subs", "chapters")
+		if err := os.MkdirAll(chaptersDir, os.ModePerm); err != nil {
+			return Result{}, fmt.Errorf("failed to create chapters directory: %v", err)
+		}
+		batch.chapterFormat = cfg.Chapters
+		batch.chapters = filepath.Join(chaptersDir, chapterFileName(epNum, path, cfg.Chapters))
 	}
-	return nil
-}
 
-// RunMerge merges subtitles and audio back into an MKV file
-func RunMerge(path string, cfg config.Config) error {
-	epNum := GetEpisodeNumber(filepath.Base(path))
+	if len(batch.tracks) == 0 && batch.chapters == "" {
+		return Result{}, fmt.Errorf("skipped")
+	}
 
-	subsSource := cfg.SubsDir
-	if subsSource == "" {
-		subsSource = filepath.Join(filepath.Dir(path), "subs", cfg.Lang)
+	// Attached fonts go next to the subtitles, shared by all episodes
+	fontsDir := filepath.Join(filepath.Dir(path), "subs", "fonts")
+	if batch.hasSubtitles() {
+		batch.fonts = planFontJobs(path, info.Attachments, fontsDir)
+		if len(batch.fonts) > 0 {
+			if err := os.MkdirAll(fontsDir, os.ModePerm); err != nil {
+				return Result{}, fmt.Errorf("failed to create fonts directory: %v", err)
+			}
+		}
 	}
 
-	audioSource := cfg.AudioDir
-	if audioSource == "" {
-		audioSource = subsSource
+	if err := batch.run(source); err != nil {
+		for _, g := range guesses {
+			os.Remove(g.tmpPath)
+		}
+		return Result{}, err
 	}
 
-	var subFile, audioFile string
-	hasSub, hasAudio := false, false
+	res := Result{Notes: notes, Languages: batch.languages()}
+	if batch.fallback != nil {
+		res.Notes = append(res.Notes, fmt.Sprintf("⚠️ NATIVE EXTRACT: %s: %v, used mkvextract", filepath.Base(path), batch.fallback))
+	}
 
-	// Search for subtitles
-	if entries, err := os.ReadDir(subsSource); err == nil {
-		for _, f := range entries {
-			if strings.HasPrefix(f.Name(), epNum) && strings.Contains(f.Name(), cfg.Lang) && !strings.HasSuffix(f.Name(), ".xml") {
-				ext := strings.ToLower(filepath.Ext(f.Name()))
-				if ext == ".srt" || ext == ".ass" {
-					subFile = filepath.Join(subsSource, f.Name())
-					hasSub = true
-					break
-				}
-			}
+	// Record the track properties the extracted files can't hold
+	var subtitles []extractJob
+	for _, job := range batch.tracks {
+		if job.lang == "" {
+			continue // Written once the language has been guessed
+		}
+		if err := writeSidecar(job.output, job.track, job.lang); err != nil {
+			return res, fmt.Errorf("failed to write track sidecar: %v", err)
+		}
+		if job.kind == "subtitle" {
+			subtitles = append(subtitles, job)
 		}
 	}
 
-	// Search for audio
-	if cfg.Audio {
-		if entries, err := os.ReadDir(audioSource); err == nil {
-			for _, f := range entries {
-				if strings.HasPrefix(f.Name(), epNum) && strings.Contains(f.Name(), cfg.Lang) && !strings.HasSuffix(f.Name(), ".xml") {
-					ext := strings.ToLower(filepath.Ext(f.Name()))
-					if isAudioExt(ext) {
-						audioFile = filepath.Join(audioSource, f.Name())
-						hasAudio = true
-						break
-					}
-				}
+	// Move guessed undefined tracks into the folder of their language
+	for _, g := range guesses {
+		lang := guessFileLanguage(g.tmpPath, g.ext)
+		switch {
+		case lang == "":
+			lang = undTarget
+		case !slices.Contains(languages, lang):
+			os.Remove(g.tmpPath)
+			res.Notes = append(res.Notes, fmt.Sprintf("🌐 UNDEFINED: %s track %d looks like %s, not requested", filepath.Base(path), g.track.ID, lang))
+			continue
+		default:
+			res.Notes = append(res.Notes, fmt.Sprintf("🌐 GUESSED: %s track %d → %s", filepath.Base(path), g.track.ID, lang))
+		}
+		if !slices.Contains(res.Languages, lang) {
+			res.Languages = append(res.Languages, lang)
+		}
+
+		subsDir := filepath.Join(filepath.Dir(path), "subs", lang)
+		if err := os.MkdirAll(subsDir, os.ModePerm); err != nil {
+			return res, fmt.Errorf("failed to create subtitle directory: %v", err)
+		}
+		outName := subtitleFileName(epNum, nameFields, g.index, g.track, lang, cfg)
+		outPath := batch.uniqueOutput(g.track, filepath.Join(subsDir, outName))
+		if filepath.Base(outPath) != outName {
+			res.Notes = append(res.Notes, nameClashNote(g.track, outPath))
+		}
+		batch.moved(g.tmpPath, outPath)
+		if err := os.Rename(g.tmpPath, outPath); err != nil {
+			return res, fmt.Errorf("subtitle extraction failed: %v", err)
+		}
+		if err := writeSidecar(outPath, g.track, lang); err != nil {
+			return res, fmt.Errorf("failed to write track sidecar: %v", err)
+		}
+		subtitles = append(subtitles, extractJob{track: g.track, kind: "subtitle", output: outPath, lang: lang})
+	}
+
+	// Convert text subtitles to the requested format
+	if cfg.Convert != "" {
+		for _, job := range subtitles {
+			note, err := convertExtracted(job, cfg)
+			if err != nil {
+				return res, fmt.Errorf("subtitle conversion failed: %v", err)
+			}
+			if note != "" {
+				res.Notes = append(res.Notes, note)
 			}
 		}
 	}
 
+	// Deduplicate the extracted fonts by content hash
+	added := 0
+	for _, job := range batch.fonts {
+		stored, err := storeFont(job)
+		if err != nil {
+			return res, fmt.Errorf("font extraction failed: %v", err)
+		}
+		if stored {
+			added++
+		}
+	}
+	if added > 0 {
+		res.Notes = append(res.Notes, fmt.Sprintf("🔤 FONTS: %s → %d new font(s)", filepath.Base(path), added))
+	}
+	return res, nil
+}
+
+// nameClashNote reports a track renamed because another track got the same name
+func nameClashNote(t Track, output string) string {
+	return fmt.Sprintf("⚠️ NAME CLASH: track %d saved as %s", t.ID, filepath.Base(output))
+}
+
+// undefinedGuess is an undefined text track waiting for its language to be guessed
+type undefinedGuess struct {
+	index   int // Position of the track in the file, used in its name
+	track   Track
+	tmpPath string
+	ext     string
+}
+
+// subtitleFileName names the extracted file of the i-th track of a video
+func subtitleFileName(epNum string, nameFields NameFields, i int, t Track, lang string, cfg config.Config) string {
+	ext := getSubtitleExtension(t.Codec, t.Props.CodecID)
+	// Handle forced/sign subtitle tracks
+	forced := strings.Contains(strings.ToLower(t.Props.TrackName), "sign") || t.Props.Forced
+	if cfg.NameTemplate != "" {
+		return RenderName(cfg.NameTemplate, trackNameFields(nameFields, t, lang, forced, ext))
+	}
+
+	suffix := ""
+	if forced {
+		suffix = "_forced"
+	} else if i > 0 {
+		suffix = fmt.Sprintf("_%d", i)
+	}
+	return fmt.Sprintf("%s_%s%s%s", epNum, lang, suffix, ext)
+}
+
+// RunMerge merges subtitles and audio back into an MKV file
+func RunMerge(path string, cfg config.Config) (Result, error) {
+	var res Result
+	assets, notes, err := mergeAssets(path, cfg)
+	res.Notes = notes
+	if err != nil {
+		return res, err
+	}
+
 	// Skip if nothing found
-	if !hasSub && !hasAudio {
-		return fmt.Errorf("skipped")
+	if len(assets.Subtitles) == 0 && len(assets.Audio) == 0 && assets.Chapters == "" {
+		return res, fmt.Errorf("skipped")
+	}
+
+	notes, err = runMkvMergeStandard(path, assets, cfg)
+	res.Notes = append(res.Notes, notes...)
+	return res, err
+}
+
+// mergeAssets returns the external files merged into a video: the entry of the pairing
+// manifest when it lists files, otherwise the discovered ones with the entry's timing
+func mergeAssets(path string, cfg config.Config) (PairingEntry, []string, error) {
+	if cfg.Pairing == "" {
+		assets, notes := DiscoverAssets(path, cfg)
+		return assets, notes, nil
+	}
+	manifest, err := cachedManifest(cfg.Pairing)
+	if err != nil {
+		return PairingEntry{}, nil, err
+	}
+
+	entry, listed := manifest.Lookup(path)
+	switch {
+	case listed && entry.hasFiles():
+		// An explicit pairing overrides automatic discovery
+		return entry, nil, nil
+	case !listed && manifest.pairsFiles():
+		return PairingEntry{}, nil, fmt.Errorf("skipped")
 	}
 
-	return runMkvMergeStandard(path, subFile, audioFile, subsSource, cfg)
+	// Entries without files only adjust the timing of the discovered subtitles
+	assets, notes := DiscoverAssets(path, cfg)
+	assets.SubDelay, assets.SubFPS = entry.SubDelay, entry.SubFPS
+	return assets, notes, nil
 }
 
-func runMkvMergeStandard(path, subFile, audioFile, subsSource string, cfg config.Config) error {
+// runMkvMergeStandard muxes the external files into the video, returning notes for the run log
+func runMkvMergeStandard(path string, assets PairingEntry, cfg config.Config) ([]string, error) {
 	info, err := GetInfo(path)
 	if err != nil {
-		return fmt.Errorf("failed to read MKV metadata: %v", err)
+		return nil, fmt.Errorf("failed to read MKV metadata: %v", err)
 	}
 
 	outRoot := cfg.OutDir
@@ -187,7 +380,7 @@ func runMkvMergeStandard(path, subFile, audioFile, subsSource string, cfg config
 	finalOutDir := filepath.Join(outRoot, filepath.Dir(relPath))
 
 	if err := os.MkdirAll(finalOutDir, os.ModePerm); err != nil {
-		return fmt.Errorf("failed to create output directory: %v", err)
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
 	}
 
 	// Ensure output filename ends in .mkv
@@ -195,23 +388,35 @@ func runMkvMergeStandard(path, subFile, audioFile, subsSource string, cfg config
 	if ext := filepath.Ext(outName); ext != ".mkv" {
 		outName = strings.TrimSuffix(outName, ext) + ".mkv"
 	}
-	args := []string{"-o", filepath.Join(finalOutDir, outName)}
+
+	args, notes, err := mergeArgs(path, filepath.Join(finalOutDir, outName), info, assets, cfg)
+	if err != nil {
+		return notes, err
+	}
+	return notes, execute("mkvmerge", args...)
+}
+
+// mergeArgs builds the mkvmerge command line muxing the external files into the video
+func mergeArgs(path, output string, info *Info, assets PairingEntry, cfg config.Config) ([]string, []string, error) {
+	args := []string{"-o", output}
 
 	// Filter audio tracks if requested
+	keepAudio := func(t Track) bool { return true }
 	if cfg.KeepOnlyAudio != "" {
 		var audioIDs []string
 		for _, t := range info.Tracks {
-			if t.Type == "audio" && t.Props.Lang == cfg.KeepOnlyAudio {
+			if t.Type == "audio" && trackMatchesLanguage(t, cfg.KeepOnlyAudio) {
 				audioIDs = append(audioIDs, fmt.Sprintf("%d", t.ID))
 			}
 		}
 		if len(audioIDs) > 0 {
 			args = append(args, "--audio-tracks", strings.Join(audioIDs, ","))
+			keepAudio = func(t Track) bool { return trackMatchesLanguage(t, cfg.KeepOnlyAudio) }
 		}
 	}
 
 	// If we are merging a new audio file, we might want to set other audio tracks as NOT default
-	if audioFile != "" {
+	if len(assets.Audio) > 0 {
 		for _, t := range info.Tracks {
 			if t.Type == "audio" {
 				args = append(args, "--default-track", fmt.Sprintf("%d:no", t.ID))
@@ -219,46 +424,115 @@ func runMkvMergeStandard(path, subFile, audioFile, subsSource string, cfg config
 		}
 	}
 
-	// Remove original subtitles
-	args = append(args, "--no-subtitles", path)
+	// Remove original subtitles (and chapters when replacing them)
+	args = append(args, "--no-subtitles")
+	if assets.Chapters != "" {
+		args = append(args, "--no-chapters")
+	}
+	args = append(args, path)
 
-	// Attach fonts if found
-	fonts, err := filepath.Glob(filepath.Join(subsSource, "*.[ot]t[f]"))
-	if err != nil {
-		return fmt.Errorf("failed to search for fonts: %v", err)
+	// Take chapters from the external file
+	if assets.Chapters != "" {
+		args = append(args, "--chapters", assets.Chapters)
 	}
-	for _, f := range fonts {
-		args = append(args, "--attach-file", f)
+
+	// Attach fonts if found, except those the video already carries (extract → merge)
+	for _, f := range assets.Fonts {
+		if !alreadyAttached(f, info.Attachments) {
+			args = append(args, "--attach-file", f)
+		}
 	}
 
-	// Add audio if found
-	if audioFile != "" {
-		args = append(args,
-			"--language", "0:"+cfg.Lang,
-			"--track-name", "0:"+strings.ToUpper(cfg.Lang),
-			"--default-track", "0:yes",
-			audioFile,
-		)
+	// Original positions of the external tracks, known from their sidecars
+	var externals []int
+	restoreOrder := false
+
+	// Add audio if found (the first one becomes the default track).
+	// IETF tags such as pt-BR are passed as-is so players show the regional variant.
+	for i, audioFile := range assets.Audio {
+		props := TrackSidecar{TrackID: -1, Type: "audio", Name: strings.ToUpper(cfg.Lang), Default: i == 0}
+		if sc, ok := loadSidecar(audioFile); ok {
+			props, restoreOrder = sc, true
+		}
+		externals = append(externals, props.TrackID)
+		args = append(args, externalTrackArgs(props, cfg.Lang)...)
+		if delay := props.syncDelay(); delay != 0 {
+			args = append(args, "--sync", fmt.Sprintf("0:%d", delay))
+		}
+		args = append(args, audioFile)
 	}
 
-	// Add subtitle if found
-	if subFile != "" {
+	var notes []string
+
+	// Add subtitles if found (the first one becomes the default track)
+	for i, subFile := range assets.Subtitles {
 		// Determine forced flag based on filename
-		forcedFlag := "0:no"
-		if strings.Contains(strings.ToLower(subFile), "forced") || strings.Contains(strings.ToLower(subFile), "sign") {
-			forcedFlag = "0:yes"
+		forced := strings.Contains(strings.ToLower(subFile), "forced") || strings.Contains(strings.ToLower(subFile), "sign")
+
+		props := TrackSidecar{TrackID: -1, Name: strings.ToUpper(cfg.Lang), Default: i == 0, Forced: forced}
+		if sc, ok := loadSidecar(subFile); ok {
+			props, restoreOrder = sc, true
+		}
+		externals = append(externals, props.TrackID)
+		args = append(args, externalTrackArgs(props, cfg.Lang)...)
+
+		// Shift or retime the subtitles when they were timed for another release
+		sync, err := subtitleSync(assets, cfg, props.syncDelay())
+		if err != nil {
+			return nil, notes, fmt.Errorf("invalid subtitle sync: %v", err)
+		}
+		if sync != "" {
+			args = append(args, "--sync", "0:"+sync)
+		}
+
+		// Legacy code pages (Windows-1252/1250) are converted to UTF-8 by mkvmerge
+		charsetArgs, note, err := subtitleCharsetArgs(subFile)
+		if err != nil {
+			return nil, notes, err
+		}
+		if note != "" {
+			args = append(args, charsetArgs...)
+			notes = append(notes, note)
+		}
+		args = append(args, subFile)
+	}
+
+	// Put extracted tracks back where they were in the source file
+	if restoreOrder {
+		var kept []Track
+		for _, t := range info.Tracks {
+			if t.Type != "subtitles" && (t.Type != "audio" || keepAudio(t)) {
+				kept = append(kept, t)
+			}
 		}
+		args = append(args, "--track-order", trackOrderArg(kept, externals))
+	}
 
-		args = append(args,
-			"--language", "0:"+cfg.Lang,
-			"--track-name", "0:"+strings.ToUpper(cfg.Lang),
-			"--default-track", "0:yes",
-			"--forced-display-flag", forcedFlag,
-			subFile,
-		)
+	return args, notes, nil
+}
+
+// externalTrackArgs sets the properties of an external track file (track 0 of its input).
+// lang is used when the file has no sidecar recording its own language.
+func externalTrackArgs(props TrackSidecar, lang string) []string {
+	args := []string{"--language", "0:" + props.language(lang)}
+	if props.Name != "" {
+		args = append(args, "--track-name", "0:"+props.Name)
+	}
+	args = append(args, "--default-track", "0:"+yesNo(props.Default))
+	if props.Type != "audio" {
+		args = append(args, "--forced-display-flag", "0:"+yesNo(props.Forced))
+	}
+	if props.HearingImpaired {
+		args = append(args, "--hearing-impaired-flag", "0:yes")
 	}
+	return args
+}
 
-	return execute("mkvmerge", args...)
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
 }
 
 func getAudioExtension(codec string) string {
@@ -287,6 +561,29 @@ func getAudioExtension(codec string) string {
 	}
 }
 
+// getSubtitleExtension maps a subtitle codec to the file extension mkvextract writes.
+// VobSub tracks produce an .idx/.sub pair; the .idx file is the one to pass around.
+func getSubtitleExtension(codec, codecID string) string {
+	codec = strings.ToLower(codec)
+	codecID = strings.ToUpper(codecID)
+	switch {
+	case codecID == "S_HDMV/PGS" || strings.Contains(codec, "pgs"):
+		return ".sup"
+	case codecID == "S_VOBSUB" || strings.Contains(codec, "vobsub"):
+		return ".idx"
+	case codecID == "S_TEXT/WEBVTT" || strings.Contains(codec, "webvtt"):
+		return ".vtt"
+	case codecID == "S_TEXT/USF" || strings.Contains(codec, "usf"):
+		return ".usf"
+	case codecID == "S_TEXT/SSA" || codecID == "S_SSA":
+		return ".ssa"
+	case strings.HasSuffix(codecID, "ASS") || strings.Contains(codec, "ass") || strings.Contains(codec, "substationalpha"):
+		return ".ass"
+	default:
+		return ".srt"
+	}
+}
+
 func isAudioExt(ext string) bool {
 	ext = strings.ToLower(ext)
 	audioExts := map[string]bool{
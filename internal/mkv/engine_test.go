@@ -1,6 +1,12 @@
 package mkv
 
-import "testing"
+import (
+	"mkvtea/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestGetAudioExtension(t *testing.T) {
 	tests := []struct {
@@ -58,3 +64,125 @@ func TestIsAudioExt(t *testing.T) {
 		}
 	}
 }
+
+func TestGetSubtitleExtension(t *testing.T) {
+	tests := []struct {
+		codec    string
+		codecID  string
+		expected string
+	}{
+		{"SubStationAlpha", "S_TEXT/ASS", ".ass"},
+		{"SubStationAlpha", "S_TEXT/SSA", ".ssa"},
+		{"SubRip/SRT", "S_TEXT/UTF8", ".srt"},
+		{"HDMV PGS", "S_HDMV/PGS", ".sup"},
+		{"VobSub", "S_VOBSUB", ".idx"},
+		{"WebVTT", "S_TEXT/WEBVTT", ".vtt"},
+		{"USF", "S_TEXT/USF", ".usf"},
+		{"HDMV PGS", "", ".sup"}, // older mkvmerge without codec_id
+		{"SubStationAlpha", "", ".ass"},
+		{"Unknown", "", ".srt"},
+	}
+
+	for _, tt := range tests {
+		got := getSubtitleExtension(tt.codec, tt.codecID)
+		if got != tt.expected {
+			t.Errorf("getSubtitleExtension(%q, %q) = %q; want %q", tt.codec, tt.codecID, got, tt.expected)
+		}
+	}
+}
+
+func TestIsSubtitleExt(t *testing.T) {
+	for _, ext := range []string{".srt", ".ASS", ".ssa", ".vtt", ".usf", ".sup", ".idx"} {
+		if !isSubtitleExt(ext) {
+			t.Errorf("isSubtitleExt(%q) = false; want true", ext)
+		}
+	}
+	for _, ext := range []string{".sub", ".xml", ".txt", ".mkv", ".ac3"} {
+		if isSubtitleExt(ext) {
+			t.Errorf("isSubtitleExt(%q) = true; want false", ext)
+		}
+	}
+}
+
+func TestToolError(t *testing.T) {
+	output := "mkvextract v80.0 ('Roundabout') 64-bit\nError: The file 'a.mp4' is not a Matroska file.\n"
+	if got := toolError([]byte(output)); got != "The file 'a.mp4' is not a Matroska file." {
+		t.Errorf("toolError = %q", got)
+	}
+	if got := toolError([]byte("Progress: 100%\n")); got != "" {
+		t.Errorf("toolError without error line = %q; want empty", got)
+	}
+}
+
+func TestMergeArgsRestoresSidecars(t *testing.T) {
+	dir := t.TempDir()
+	info := &Info{Tracks: []Track{{ID: 0, Type: "video"}, {ID: 1, Type: "audio"}, {ID: 2, Type: "subtitles"}}}
+
+	// An AAC track with a codec delay and a subtitle track tagged und, filed under ita
+	audio := Track{ID: 1, Type: "audio"}
+	audio.Props.Lang, audio.Props.LangIETF = "jpn", "ja"
+	audio.Props.CodecID, audio.Props.CodecDelay = "A_AAC", 6500000
+	sub := Track{ID: 2, Type: "subtitles"}
+	sub.Props.Lang = undefinedLang
+	opus := Track{ID: 3, Type: "audio"}
+	opus.Props.Lang, opus.Props.CodecID, opus.Props.CodecDelay = "eng", "A_OPUS", 6500000
+
+	assets := PairingEntry{
+		Audio:     []string{filepath.Join(dir, "05_jpn_1.aac"), filepath.Join(dir, "05_eng_3.opus")},
+		Subtitles: []string{filepath.Join(dir, "05_ita.ass")},
+	}
+	if err := os.WriteFile(assets.Subtitles[0], []byte("[Script Info]\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	for i, track := range []Track{audio, opus, sub} {
+		file := append(assets.Audio, assets.Subtitles...)[i]
+		if err := writeSidecar(file, track, "ita"); err != nil {
+			t.Fatalf("writeSidecar failed: %v", err)
+		}
+	}
+
+	args, _, err := mergeArgs(filepath.Join(dir, "05.mkv"), filepath.Join(dir, "out.mkv"), info, assets, config.Config{Lang: "ita"})
+	if err != nil {
+		t.Fatalf("mergeArgs failed: %v", err)
+	}
+	got := strings.Join(args, " ")
+	for _, want := range []string{
+		"--language 0:ja --default-track 0:no --sync 0:-7 " + assets.Audio[0],
+		"--language 0:eng --default-track 0:no " + assets.Audio[1],
+		"--language 0:ita --default-track 0:no --forced-display-flag 0:no " + assets.Subtitles[0],
+		"--track-order 0:0,0:1,1:0,3:0,2:0",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("mergeArgs = %q\nmissing %q", got, want)
+		}
+	}
+}
+
+func TestMergeArgsCombinesSync(t *testing.T) {
+	dir := t.TempDir()
+	info := &Info{Tracks: []Track{{ID: 0, Type: "video"}, {ID: 1, Type: "subtitles"}}}
+
+	// A subtitle track with a codec delay, shifted and retimed by the user
+	sub := Track{ID: 1, Type: "subtitles"}
+	sub.Props.Lang, sub.Props.CodecDelay = "ita", 7000000
+	assets := PairingEntry{Subtitles: []string{filepath.Join(dir, "05_ita.srt")}}
+	if err := os.WriteFile(assets.Subtitles[0], []byte("1\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := writeSidecar(assets.Subtitles[0], sub, "ita"); err != nil {
+		t.Fatalf("writeSidecar failed: %v", err)
+	}
+
+	cfg := config.Config{Lang: "ita", SubDelay: 500, SubFPS: "25:23.976"}
+	args, _, err := mergeArgs(filepath.Join(dir, "05.mkv"), filepath.Join(dir, "out.mkv"), info, assets, cfg)
+	if err != nil {
+		t.Fatalf("mergeArgs failed: %v", err)
+	}
+	got := strings.Join(args, " ")
+	if n := strings.Count(got, "--sync"); n != 1 {
+		t.Errorf("mergeArgs = %q\nhas %d --sync options; want one", got, n)
+	}
+	if want := "--sync 0:493,3125/2997 " + assets.Subtitles[0]; !strings.Contains(got, want) {
+		t.Errorf("mergeArgs = %q\nmissing %q", got, want)
+	}
+}
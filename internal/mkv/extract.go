@@ -0,0 +1,206 @@
+package mkv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// extractJob is a track written by mkvextract
+type extractJob struct {
+	track  Track
+	kind   string // "subtitle" or "audio", used in error messages
+	output string
+	lang   string // Language folder of the output, "" while still unknown
+}
+
+// extractBatch collects everything extracted from one file, so that mkvextract reads
+// the file only once
+type extractBatch struct {
+	tracks        []extractJob
+	fonts         []fontJob
+	chapters      string // Output path of the chapters, "" when not extracted
+	chapterFormat string
+	fallback      error // Why native extraction of a text-only batch fell back to mkvextract
+}
+
+// addTrack selects a track for extraction and returns the file it will be written to
+func (b *extractBatch) addTrack(t Track, kind, output, lang string) string {
+	output = b.uniqueOutput(t, output)
+	b.tracks = append(b.tracks, extractJob{track: t, kind: kind, output: output, lang: lang})
+	return output
+}
+
+// uniqueOutput returns output, with the track ID appended when another track of the
+// batch is already written there (name templates without {track_id} or {forced})
+func (b *extractBatch) uniqueOutput(t Track, output string) string {
+	for _, job := range b.tracks {
+		if job.output == output && job.track.ID != t.ID {
+			ext := filepath.Ext(output)
+			return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(output, ext), t.ID, ext)
+		}
+	}
+	return output
+}
+
+// moved records that the track extracted to from now lives at to
+func (b *extractBatch) moved(from, to string) {
+	for i := range b.tracks {
+		if b.tracks[i].output == from {
+			b.tracks[i].output = to
+		}
+	}
+}
+
+// languages returns the language folders written by the batch, in order of appearance
+func (b *extractBatch) languages() []string {
+	var langs []string
+	for _, job := range b.tracks {
+		if job.lang != "" && !slices.Contains(langs, job.lang) {
+			langs = append(langs, job.lang)
+		}
+	}
+	return langs
+}
+
+func (b *extractBatch) hasSubtitles() bool {
+	for _, job := range b.tracks {
+		if job.kind == "subtitle" {
+			return true
+		}
+	}
+	return false
+}
+
+// args builds the mkvextract command line. A track selected for several outputs
+// is extracted once; the copies are made by run.
+func (b *extractBatch) args(path string) []string {
+	args := []string{path}
+
+	seen := map[int]bool{}
+	var tracks []string
+	for _, job := range b.tracks {
+		if seen[job.track.ID] {
+			continue
+		}
+		seen[job.track.ID] = true
+		tracks = append(tracks, fmt.Sprintf("%d:%s", job.track.ID, job.output))
+	}
+	if len(tracks) > 0 {
+		args = append(args, "tracks")
+		args = append(args, tracks...)
+	}
+
+	if len(b.fonts) > 0 {
+		args = append(args, "attachments")
+		for _, job := range b.fonts {
+			args = append(args, fmt.Sprintf("%d:%s", job.attachment.ID, job.tmpPath))
+		}
+	}
+
+	if b.chapters != "" {
+		args = append(args, chapterArgs(b.chapterFormat, b.chapters)...)
+	}
+	return args
+}
+
+// textOnly reports whether every selected track is a text subtitle and no chapters
+// are requested, so that the batch can be extracted without mkvextract
+func (b *extractBatch) textOnly() bool {
+	if len(b.tracks) == 0 || b.chapters != "" {
+		return false
+	}
+	for _, job := range b.tracks {
+		if job.kind != "subtitle" || !isTextCodecID(job.track.Props.CodecID) {
+			return false
+		}
+	}
+	return true
+}
+
+// outputs maps each selected track ID to the first file it is written to
+func (b *extractBatch) outputs() map[int]string {
+	outputs := map[int]string{}
+	for _, job := range b.tracks {
+		if _, ok := outputs[job.track.ID]; !ok {
+			outputs[job.track.ID] = job.output
+		}
+	}
+	return outputs
+}
+
+// run executes the batch, reading text subtitles natively when possible and falling
+// back to mkvextract otherwise; b.fallback keeps the reason. On failure the error names
+// the first track whose output is missing, as the per-track calls used to do.
+func (b *extractBatch) run(path string) error {
+	native := b.textOnly()
+	if native {
+		b.fallback = extractTextNative(path, b.outputs(), b.fonts)
+	}
+	if !native || b.fallback != nil {
+		if err := execute("mkvextract", b.args(path)...); err != nil {
+			for _, job := range b.fonts {
+				os.Remove(job.tmpPath)
+			}
+			if b.fallback != nil {
+				err = fmt.Errorf("%v (native extraction: %v)", err, b.fallback)
+			}
+			return b.describeFailure(err)
+		}
+	}
+
+	// Copy tracks that were selected for more than one output
+	first := map[int]string{}
+	for _, job := range b.tracks {
+		src, ok := first[job.track.ID]
+		if !ok {
+			first[job.track.ID] = job.output
+			continue
+		}
+		if err := copyFile(src, job.output); err != nil {
+			return fmt.Errorf("%s extraction failed: %v", job.kind, err)
+		}
+	}
+	return nil
+}
+
+func (b *extractBatch) describeFailure(err error) error {
+	for _, job := range b.tracks {
+		if _, statErr := os.Stat(job.output); statErr != nil {
+			return fmt.Errorf("%s extraction failed: track %d: %v", job.kind, job.track.ID, err)
+		}
+	}
+	if b.chapters != "" {
+		if _, statErr := os.Stat(b.chapters); statErr != nil {
+			return fmt.Errorf("chapter extraction failed: %v", err)
+		}
+	}
+	if len(b.fonts) > 0 {
+		return fmt.Errorf("font extraction failed: %v", err)
+	}
+	if len(b.tracks) > 0 {
+		return fmt.Errorf("%s extraction failed: %v", b.tracks[0].kind, err)
+	}
+	return fmt.Errorf("extraction failed: %v", err)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
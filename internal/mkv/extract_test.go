@@ -0,0 +1,72 @@
+package mkv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractBatchArgs(t *testing.T) {
+	var batch extractBatch
+	batch.addTrack(Track{ID: 2}, "subtitle", "subs/ita/05_ita_2.ass", "ita")
+	batch.addTrack(Track{ID: 1}, "audio", "subs/ita/05_ita_1.aac", "ita")
+	batch.addTrack(Track{ID: 2}, "subtitle", "subs/eng/05_eng_2.ass", "eng")
+	batch.fonts = []fontJob{{attachment: Attachment{ID: 1}, tmpPath: "subs/fonts/.a.1.tmp"}}
+	batch.chapterFormat = "simple"
+	batch.chapters = "subs/chapters/05_chapters.txt"
+
+	got := strings.Join(batch.args("ep05.mkv"), " ")
+	want := "ep05.mkv tracks 2:subs/ita/05_ita_2.ass 1:subs/ita/05_ita_1.aac" +
+		" attachments 1:subs/fonts/.a.1.tmp chapters --simple subs/chapters/05_chapters.txt"
+	if got != want {
+		t.Errorf("args = %q; want %q", got, want)
+	}
+
+	if got := strings.Join(batch.languages(), ","); got != "ita,eng" {
+		t.Errorf("languages = %q; want ita,eng", got)
+	}
+
+	if got := strings.Join((&extractBatch{chapters: "c.xml", chapterFormat: "xml"}).args("a.mkv"), " "); got != "a.mkv chapters c.xml" {
+		t.Errorf("chapters-only args = %q", got)
+	}
+}
+
+func TestExtractBatchNameClash(t *testing.T) {
+	// A template without {track_id} names both Italian tracks "Show - 05.ita.ass"
+	var batch extractBatch
+	first := batch.addTrack(Track{ID: 2}, "subtitle", "subs/ita/Show - 05.ita.ass", "ita")
+	second := batch.addTrack(Track{ID: 3}, "subtitle", "subs/ita/Show - 05.ita.ass", "ita")
+	if first != "subs/ita/Show - 05.ita.ass" || second != "subs/ita/Show - 05.ita_3.ass" {
+		t.Errorf("addTrack = %q, %q; want the second track renamed", first, second)
+	}
+
+	got := strings.Join(batch.args("ep05.mkv"), " ")
+	want := "ep05.mkv tracks 2:subs/ita/Show - 05.ita.ass 3:subs/ita/Show - 05.ita_3.ass"
+	if got != want {
+		t.Errorf("args = %q; want %q", got, want)
+	}
+
+	// The same track may still be written to several folders
+	if out := batch.addTrack(Track{ID: 2}, "subtitle", "subs/ita/Show - 05.ita.ass", "ita"); out != first {
+		t.Errorf("addTrack of the same track = %q; want %q", out, first)
+	}
+}
+
+func TestExtractBatchFailure(t *testing.T) {
+	dir := t.TempDir()
+	written := filepath.Join(dir, "05_ita_2.ass")
+	if err := os.WriteFile(written, []byte("test"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var batch extractBatch
+	batch.addTrack(Track{ID: 2}, "subtitle", written, "ita")
+	batch.addTrack(Track{ID: 1}, "audio", filepath.Join(dir, "05_ita_1.aac"), "ita")
+
+	err := batch.describeFailure(errors.New("mkvextract command failed: exit status 2"))
+	if err == nil || !strings.HasPrefix(err.Error(), "audio extraction failed: track 1") {
+		t.Errorf("describeFailure = %v; want the missing audio track reported", err)
+	}
+}
@@ -0,0 +1,173 @@
+package mkv
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// fontExts lists the file extensions treated as fonts
+var fontExts = map[string]bool{".ttf": true, ".otf": true, ".ttc": true, ".woff": true, ".woff2": true}
+
+// fontJob is a font attachment extracted to a temporary file, waiting to be deduplicated
+type fontJob struct {
+	attachment Attachment
+	tmpPath    string
+}
+
+var (
+	fontIndexMu sync.Mutex
+	// fontIndex maps a fonts directory to the SHA-256 digests of the fonts it holds
+	fontIndex = map[string]map[string]string{}
+)
+
+// isFontAttachment reports whether an attachment is a font, by MIME type or file extension
+func isFontAttachment(a Attachment) bool {
+	ct := strings.ToLower(a.ContentType)
+	if strings.Contains(ct, "font") || strings.Contains(ct, "opentype") || strings.Contains(ct, "sfnt") {
+		return true
+	}
+	return isFontFile(a.FileName)
+}
+
+func isFontFile(name string) bool {
+	return fontExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// planFontJobs assigns a temporary output path in fontsDir to every font attachment
+func planFontJobs(path string, attachments []Attachment, fontsDir string) []fontJob {
+	var jobs []fontJob
+	for _, a := range attachments {
+		if !isFontAttachment(a) {
+			continue
+		}
+		tmp := filepath.Join(fontsDir, fmt.Sprintf(".%s.%d.tmp", filepath.Base(path), a.ID))
+		jobs = append(jobs, fontJob{attachment: a, tmpPath: tmp})
+	}
+	return jobs
+}
+
+// storeFont moves an extracted font into its directory unless a font with identical content
+// is already there. A different font with the same name is stored with a hash suffix.
+// It returns true when a new file was added.
+func storeFont(job fontJob) (bool, error) {
+	digest, err := fileSHA256(job.tmpPath)
+	if err != nil {
+		os.Remove(job.tmpPath)
+		return false, err
+	}
+	dir := filepath.Dir(job.tmpPath)
+
+	fontIndexMu.Lock()
+	defer fontIndexMu.Unlock()
+
+	index, err := loadFontIndex(dir)
+	if err != nil {
+		os.Remove(job.tmpPath)
+		return false, err
+	}
+	if _, exists := index[digest]; exists {
+		return false, os.Remove(job.tmpPath)
+	}
+
+	name := filepath.Base(job.attachment.FileName)
+	if name == "." || name == "" {
+		name = fmt.Sprintf("font_%s.ttf", digest[:8])
+	}
+	if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+		ext := filepath.Ext(name)
+		name = fmt.Sprintf("%s_%s%s", strings.TrimSuffix(name, ext), digest[:8], ext)
+	}
+
+	if err := os.Rename(job.tmpPath, filepath.Join(dir, name)); err != nil {
+		os.Remove(job.tmpPath)
+		return false, fmt.Errorf("failed to store font %s: %v", name, err)
+	}
+	index[digest] = name
+	return true, nil
+}
+
+// loadFontIndex hashes the fonts already present in dir, once per run. Callers hold fontIndexMu.
+func loadFontIndex(dir string) (map[string]string, error) {
+	if index, ok := fontIndex[dir]; ok {
+		return index, nil
+	}
+
+	index := map[string]string{}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read fonts directory: %v", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !isFontFile(e.Name()) {
+			continue
+		}
+		digest, err := fileSHA256(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		index[digest] = e.Name()
+	}
+	fontIndex[dir] = index
+	return index, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open font: %v", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read font: %v", err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// storedFontRe matches the digest suffix storeFont gives a font whose name was taken
+var storedFontRe = regexp.MustCompile(`_[0-9a-f]{8}$`)
+
+// alreadyAttached reports whether a font file is one of the attachments of the video,
+// by name (ignoring the digest suffix added by storeFont) and size
+func alreadyAttached(font string, attachments []Attachment) bool {
+	fi, err := os.Stat(font)
+	if err != nil {
+		return false
+	}
+	name := filepath.Base(font)
+	ext := filepath.Ext(name)
+	stripped := storedFontRe.ReplaceAllString(strings.TrimSuffix(name, ext), "") + ext
+	for _, a := range attachments {
+		if a.Size == fi.Size() && (strings.EqualFold(a.FileName, name) || strings.EqualFold(a.FileName, stripped)) {
+			return true
+		}
+	}
+	return false
+}
+
+// findFonts lists the fonts stored in the given directories, skipping duplicates by name
+func findFonts(dirs ...string) []string {
+	var fonts []string
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || !isFontFile(e.Name()) || seen[e.Name()] {
+				continue
+			}
+			seen[e.Name()] = true
+			fonts = append(fonts, filepath.Join(dir, e.Name()))
+		}
+	}
+	return fonts
+}
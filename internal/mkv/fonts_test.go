@@ -0,0 +1,92 @@
+package mkv
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestIsFontAttachment(t *testing.T) {
+	tests := []struct {
+		attachment Attachment
+		expected   bool
+	}{
+		{Attachment{FileName: "Arial.ttf", ContentType: "application/x-truetype-font"}, true},
+		{Attachment{FileName: "Font.otf", ContentType: "application/vnd.ms-opentype"}, true},
+		{Attachment{FileName: "font.bin", ContentType: "font/woff2"}, true},
+		{Attachment{FileName: "Roboto.TTF", ContentType: "application/octet-stream"}, true},
+		{Attachment{FileName: "cover.jpg", ContentType: "image/jpeg"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := isFontAttachment(tt.attachment); got != tt.expected {
+			t.Errorf("isFontAttachment(%+v) = %v; want %v", tt.attachment, got, tt.expected)
+		}
+	}
+}
+
+func TestStoreFontDeduplicates(t *testing.T) {
+	dir := t.TempDir()
+	store := func(episode int, name, content string) bool {
+		t.Helper()
+		job := planFontJobs(filepath.Join(dir, "ep.mkv"), []Attachment{{ID: episode, FileName: name, ContentType: "font/ttf"}}, dir)[0]
+		if err := os.WriteFile(job.tmpPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create font: %v", err)
+		}
+		stored, err := storeFont(job)
+		if err != nil {
+			t.Fatalf("storeFont failed: %v", err)
+		}
+		return stored
+	}
+
+	if !store(1, "Arial.ttf", "arial") {
+		t.Errorf("first font should be stored")
+	}
+	if store(2, "Arial.ttf", "arial") {
+		t.Errorf("identical font from another episode should be deduplicated")
+	}
+	if store(3, "ArialCopy.ttf", "arial") {
+		t.Errorf("identical content under another name should be deduplicated")
+	}
+	if !store(4, "Arial.ttf", "arial v2") {
+		t.Errorf("different font with the same name should be stored")
+	}
+
+	var names []string
+	for _, f := range findFonts(dir) {
+		names = append(names, filepath.Base(f))
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "Arial.ttf" {
+		t.Errorf("unexpected fonts directory content: %v", names)
+	}
+}
+
+func TestAlreadyAttached(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		return path
+	}
+	attachments := []Attachment{{ID: 1, FileName: "Roboto.ttf", Size: 1000}, {ID: 2, FileName: "Lato.otf", Size: 500}}
+
+	tests := []struct {
+		font     string
+		expected bool
+	}{
+		{write("Roboto.ttf", 1000), true},
+		{write("Lato_0a1b2c3d.otf", 500), true}, // Stored under another name by storeFont
+		{write("Arial.ttf", 1000), false},
+		{write("Lato.ttf", 400), false},
+	}
+	for _, tt := range tests {
+		if got := alreadyAttached(tt.font, attachments); got != tt.expected {
+			t.Errorf("alreadyAttached(%s) = %v; want %v", filepath.Base(tt.font), got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package mkv
+
+import (
+	"fmt"
+	"mkvtea/internal/config"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ambiguityMargin is the minimum lead the best fuzzy candidate needs over the runner-up
+const ambiguityMargin = 0.05
+
+// DefaultMatchThreshold is the minimum similarity accepted when cfg.MatchThreshold is unset
+const DefaultMatchThreshold = 0.6
+
+// noiseWords are tokens that say nothing about which video a file belongs to
+var noiseWords = map[string]bool{
+	"forced": true, "sign": true, "signs": true, "songs": true, "full": true,
+	"sub": true, "subs": true, "subtitle": true, "subtitles": true,
+}
+
+type fuzzyCandidate struct {
+	path  string
+	score float64
+}
+
+// forcedWords mark a forced or signs track, which loses ties against the main track
+var forcedWords = map[string]bool{"forced": true, "sign": true, "signs": true}
+
+// findSimilarFile pairs a video with the file in dir whose normalized name is most similar.
+// It refuses matches below the threshold and ambiguous ones where the runner-up is within
+// ambiguityMargin of the best score, unless only one of the tied files is a main track
+// (Movie_ita.ass over Movie_ita_forced.ass). The returned note describes the decision for the log.
+func findSimilarFile(video, dir string, acceptExt func(ext string) bool, cfg config.Config) (string, string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", ""
+	}
+
+	threshold := cfg.MatchThreshold
+	if threshold <= 0 {
+		threshold = DefaultMatchThreshold
+	}
+
+	videoName := normalizeName(filepath.Base(video), cfg.Lang)
+	var candidates []fuzzyCandidate
+	for _, f := range entries {
+		if f.IsDir() || !acceptExt(strings.ToLower(filepath.Ext(f.Name()))) {
+			continue
+		}
+		score := nameSimilarity(videoName, normalizeName(f.Name(), cfg.Lang))
+		candidates = append(candidates, fuzzyCandidate{path: filepath.Join(dir, f.Name()), score: score})
+	}
+	if len(candidates) == 0 {
+		return "", ""
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	best := candidates[0]
+	videoBase := filepath.Base(video)
+
+	if best.score < threshold {
+		return "", fmt.Sprintf("🔍 NO MATCH: %s (best %s, confidence %.2f < %.2f)",
+			videoBase, filepath.Base(best.path), best.score, threshold)
+	}
+	tied := candidates[:1]
+	for _, c := range candidates[1:] {
+		if best.score-c.score < ambiguityMargin {
+			tied = append(tied, c)
+		}
+	}
+	if len(tied) == 1 {
+		return best.path, fmt.Sprintf("🔗 PAIRED: %s ↔ %s (confidence %.2f)", videoBase, filepath.Base(best.path), best.score)
+	}
+
+	var main []fuzzyCandidate
+	var forced []string
+	for _, c := range tied {
+		if isForcedName(filepath.Base(c.path)) {
+			forced = append(forced, filepath.Base(c.path))
+		} else {
+			main = append(main, c)
+		}
+	}
+	if len(main) == 1 {
+		return main[0].path, fmt.Sprintf("🔗 PAIRED: %s ↔ %s (confidence %.2f, ignored %s)",
+			videoBase, filepath.Base(main[0].path), main[0].score, strings.Join(forced, ", "))
+	}
+	return "", fmt.Sprintf("⚠️ AMBIGUOUS: %s (%s %.2f vs %s %.2f)",
+		videoBase, filepath.Base(best.path), best.score, filepath.Base(candidates[1].path), candidates[1].score)
+}
+
+// isForcedName reports whether a file name marks a forced or signs track
+func isForcedName(name string) bool {
+	for _, w := range nameWords(strings.TrimSuffix(name, filepath.Ext(name))) {
+		if forcedWords[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeName reduces a file name to lower-case words, dropping the extension,
+// bracketed tags, the language code and other noise words
+func normalizeName(name, lang string) string {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	name = bracketRe.ReplaceAllString(name, " ")
+
+	words := nameWords(name)
+	kept := words[:0]
+	for _, w := range words {
+		if w == strings.ToLower(lang) || noiseWords[w] {
+			continue
+		}
+		kept = append(kept, w)
+	}
+	return strings.Join(kept, " ")
+}
+
+// nameWords splits a name into lower-case words
+func nameWords(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	})
+}
+
+// nameSimilarity returns the Sørensen–Dice coefficient of the character bigrams of a and b (0-1)
+func nameSimilarity(a, b string) float64 {
+	if a == b {
+		if a == "" {
+			return 0
+		}
+		return 1
+	}
+	ba, bb := bigrams(a), bigrams(b)
+	if len(ba) == 0 || len(bb) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(ba))
+	for _, g := range ba {
+		counts[g]++
+	}
+	shared := 0
+	for _, g := range bb {
+		if counts[g] > 0 {
+			counts[g]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ba)+len(bb))
+}
+
+func bigrams(s string) []string {
+	r := []rune(s)
+	if len(r) < 2 {
+		return nil
+	}
+	out := make([]string, 0, len(r)-1)
+	for i := 0; i < len(r)-1; i++ {
+		out = append(out, string(r[i:i+2]))
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package mkv
+
+import (
+	"mkvtea/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"[Group] Show - The Movie [1080p][ABCD1234].mkv", "show the movie"},
+		{"Show_-_The_Movie_ita_forced.ass", "show the movie"},
+		{"Show.The.Movie.ITA.srt", "show the movie"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeName(tt.name, "ita"); got != tt.expected {
+			t.Errorf("normalizeName(%q) = %q; want %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	if got := nameSimilarity("show the movie", "show the movie"); got != 1 {
+		t.Errorf("identical names: got %.2f; want 1", got)
+	}
+	if got := nameSimilarity("abc", "xyz"); got != 0 {
+		t.Errorf("unrelated names: got %.2f; want 0", got)
+	}
+	near := nameSimilarity("show the movie", "show movie")
+	far := nameSimilarity("show the movie", "show ova")
+	if near <= far {
+		t.Errorf("expected %.2f > %.2f", near, far)
+	}
+}
+
+func TestFindSimilarFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"Show - The Movie_ita.ass",
+		"Show - Special Recap_ita.ass",
+		"Show - Special Recap_ita.srt",
+		"Unrelated_ita.srt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	cfg := config.Config{Lang: "ita", MatchThreshold: 0.6}
+
+	got, note := findSimilarFile("[Grp] Show - The Movie [1080p].mkv", dir, isSubtitleExt, cfg)
+	if filepath.Base(got) != "Show - The Movie_ita.ass" || !strings.HasPrefix(note, "🔗 PAIRED") {
+		t.Errorf("movie: got %q (%s)", got, note)
+	}
+
+	// Two equally similar candidates must be refused
+	got, note = findSimilarFile("Show - Special Recap.mkv", dir, isSubtitleExt, cfg)
+	if got != "" || !strings.Contains(note, "AMBIGUOUS") {
+		t.Errorf("ambiguous: got %q (%s)", got, note)
+	}
+
+	// Nothing similar enough
+	got, note = findSimilarFile("Completely Different Title.mkv", dir, isSubtitleExt, cfg)
+	if got != "" || !strings.Contains(note, "NO MATCH") {
+		t.Errorf("below threshold: got %q (%s)", got, note)
+	}
+}
+
+func TestFindSimilarFilePrefersMainTrack(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"My Movie_ita.ass", "My Movie_ita_forced.ass", "My Movie_ita_signs.ass"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	cfg := config.Config{Lang: "ita", MatchThreshold: 0.6}
+
+	got, note := findSimilarFile("My Movie.mkv", dir, isSubtitleExt, cfg)
+	if filepath.Base(got) != "My Movie_ita.ass" || !strings.Contains(note, "ignored My Movie_ita_forced.ass, My Movie_ita_signs.ass") {
+		t.Errorf("got %q (%s); want the main track", got, note)
+	}
+}
+
+func TestDiscoverAssetsUnnumberedVideo(t *testing.T) {
+	dir := t.TempDir()
+	subs := filepath.Join(dir, "subs", "ita")
+	if err := os.MkdirAll(subs, 0755); err != nil {
+		t.Fatalf("Failed to create subs dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(subs, "XX_ita.ass"), []byte("test"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Names written by extract keep pairing in auto mode
+	cfg := config.Config{Lang: "ita", Match: "auto"}
+	entry, notes := DiscoverAssets(filepath.Join(dir, "My Movie.mkv"), cfg)
+	if len(entry.Subtitles) != 1 || filepath.Base(entry.Subtitles[0]) != "XX_ita.ass" {
+		t.Errorf("auto: subtitles = %v (%v); want XX_ita.ass", entry.Subtitles, notes)
+	}
+
+	// Without an XX file, auto falls back to name similarity
+	if err := os.Rename(filepath.Join(subs, "XX_ita.ass"), filepath.Join(subs, "My Movie_ita.ass")); err != nil {
+		t.Fatalf("Failed to rename test file: %v", err)
+	}
+	entry, notes = DiscoverAssets(filepath.Join(dir, "My Movie.mkv"), cfg)
+	if len(entry.Subtitles) != 1 || filepath.Base(entry.Subtitles[0]) != "My Movie_ita.ass" {
+		t.Errorf("fuzzy fallback: subtitles = %v (%v); want My Movie_ita.ass", entry.Subtitles, notes)
+	}
+}
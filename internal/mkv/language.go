@@ -0,0 +1,272 @@
+package mkv
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// AllLanguages is the --lang value that extracts every track whatever its language
+const AllLanguages = "all"
+
+// iso639Table lists ISO 639-2 bibliographic and terminologic codes, ISO 639-1 codes
+// and English names, one language per line
+//
+//go:embed iso639.tsv
+var iso639Table string
+
+// languageIndex maps every lowercase code and name of the table to the ISO 639-2/B
+// code, which is what mkvmerge reports as a track's language. Codes are indexed before
+// names, so that a name can never hide a code ("ga" is Irish, not the Ga language), and
+// full names before the short aliases of qualified names ("Greek, Modern (1453-)" → greek).
+var languageIndex, languageAlpha2 = func() (map[string]string, map[string]string) {
+	index := map[string]string{}
+	alpha2 := map[string]string{} // ISO 639-2/B → ISO 639-1
+	var rows [][]string
+	for _, line := range strings.Split(iso639Table, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rows = append(rows, fields)
+		if fields[2] != "-" {
+			alpha2[fields[0]] = fields[2]
+		}
+	}
+	add := func(key, bib string) {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" || key == "-" {
+			return
+		}
+		if _, taken := index[key]; !taken {
+			index[key] = bib
+		}
+	}
+
+	for _, fields := range rows {
+		for _, code := range fields[:3] {
+			add(code, fields[0])
+		}
+	}
+	for _, fields := range rows {
+		for _, name := range strings.Split(fields[3], ";") {
+			add(name, fields[0])
+		}
+	}
+	// An alias shared by several languages goes to a modern one (with an ISO 639-1 code)
+	for _, modern := range []bool{true, false} {
+		for _, fields := range rows {
+			if (fields[2] != "-") != modern {
+				continue
+			}
+			for _, name := range strings.Split(fields[3], ";") {
+				if i := strings.IndexAny(name, ",("); i > 0 {
+					add(name[:i], fields[0])
+				}
+			}
+		}
+	}
+	return index, alpha2
+}()
+
+// ietfFromISO639 derives the BCP 47 tag of an ISO 639-2 code the way mkvmerge does
+// for files without a LanguageBCP47 element: the two-letter code when one exists
+func ietfFromISO639(lang string) string {
+	if code, ok := languageAlpha2[canonicalLanguage(lang)]; ok {
+		return code
+	}
+	return lang
+}
+
+// iso639FromIETF derives the ISO 639-2/B code of a BCP 47 tag the way mkvmerge does
+// (pt-BR → por); tags with an unknown language give "und"
+func iso639FromIETF(tag string) string {
+	primary := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+	if bib, ok := languageIndex[primary]; ok {
+		return bib
+	}
+	return undefinedLang
+}
+
+// NormalizeLanguage turns a language given by the user (it, ita, italian, fra, fre)
+// into the ISO 639-2/B code used for matching tracks and naming folders. IETF tags
+// keep their regional subtags (pt-br, por-BR → pt-BR) once their language subtag is known.
+// Unknown codes return an error suggesting the closest known ones.
+func NormalizeLanguage(lang string) (string, error) {
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		return "", nil
+	}
+	if isLanguageTag(lang) {
+		primary := strings.SplitN(lang, "-", 2)[0]
+		if _, ok := languageIndex[strings.ToLower(primary)]; !ok {
+			return "", unknownLanguageError(primary)
+		}
+		return canonicalIETF(lang), nil
+	}
+	if bib, ok := languageIndex[strings.ToLower(lang)]; ok {
+		return bib, nil
+	}
+	return "", unknownLanguageError(lang)
+}
+
+// canonicalLanguage returns the code tracks are compared on, leaving unknown codes as they are
+func canonicalLanguage(lang string) string {
+	if isLanguageTag(lang) {
+		return canonicalIETF(lang)
+	}
+	if bib, ok := languageIndex[strings.ToLower(lang)]; ok {
+		return bib
+	}
+	return lang
+}
+
+// canonicalIETF normalizes a BCP 47 tag the way files store it: the shortest code for
+// its language subtag (por-BR → pt-BR) and canonical case for the others
+func canonicalIETF(tag string) string {
+	tag = CanonicalTag(tag)
+	primary, rest, _ := strings.Cut(tag, "-")
+	if bib, ok := languageIndex[primary]; ok {
+		if code, ok := languageAlpha2[bib]; ok {
+			primary = code
+		}
+	}
+	return primary + "-" + rest
+}
+
+func unknownLanguageError(lang string) error {
+	if suggestions := suggestLanguages(lang); len(suggestions) > 0 {
+		return fmt.Errorf("unknown language %q (did you mean %s?)", lang, strings.Join(suggestions, ", "))
+	}
+	return fmt.Errorf("unknown language %q", lang)
+}
+
+// suggestLanguages returns up to three codes whose code or name is close to lang
+func suggestLanguages(lang string) []string {
+	lang = strings.ToLower(lang)
+	maxDistance := 1
+	if len(lang) > 3 {
+		maxDistance = 2
+	}
+
+	best := map[string]int{}
+	for key, bib := range languageIndex {
+		d := editDistance(lang, key)
+		if len(lang) > 3 && strings.HasPrefix(key, lang) {
+			d = 0
+		}
+		if prev, ok := best[bib]; d <= maxDistance && (!ok || d < prev) {
+			best[bib] = d
+		}
+	}
+
+	suggestions := make([]string, 0, len(best))
+	for bib := range best {
+		suggestions = append(suggestions, bib)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if best[suggestions[i]] != best[suggestions[j]] {
+			return best[suggestions[i]] < best[suggestions[j]]
+		}
+		return suggestions[i] < suggestions[j]
+	})
+	if len(suggestions) > 3 {
+		suggestions = suggestions[:3]
+	}
+	return suggestions
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// isLanguageTag reports whether lang is an IETF BCP 47 tag with subtags (pt-BR, es-419)
+// rather than a bare ISO 639 code
+func isLanguageTag(lang string) bool {
+	return strings.Contains(lang, "-")
+}
+
+// CanonicalTag normalizes the case of an IETF BCP 47 tag: lowercase language,
+// titlecase script and uppercase region (pt-br → pt-BR, zh-hant-tw → zh-Hant-TW)
+func CanonicalTag(tag string) string {
+	parts := strings.Split(tag, "-")
+	for i, p := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(p)
+		case len(p) == 4 && isLetters(p):
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		case len(p) == 2 && isLetters(p), len(p) == 3 && !isLetters(p):
+			parts[i] = strings.ToUpper(p)
+		default:
+			parts[i] = strings.ToLower(p)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+func isLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// trackMatchesLanguage reports whether a track is in the requested language.
+// Full tags (pt-BR) are compared with the track's language_ietf, so regional variants
+// stay apart; bare codes (por) keep matching the ISO 639-2 language of every variant,
+// whichever of the B/T codes (ger/deu) the file uses.
+func trackMatchesLanguage(t Track, lang string) bool {
+	if isLanguageTag(lang) {
+		return t.Props.LangIETF != "" && canonicalIETF(t.Props.LangIETF) == canonicalIETF(lang)
+	}
+	return canonicalLanguage(t.Props.Lang) == canonicalLanguage(lang)
+}
+
+// trackLanguage returns the folder name of a track in "all" mode: its IETF tag when it
+// carries a region or script (pt-BR), otherwise its ISO 639-2/B code
+func trackLanguage(t Track) string {
+	if isLanguageTag(t.Props.LangIETF) {
+		return CanonicalTag(t.Props.LangIETF)
+	}
+	if t.Props.Lang == "" {
+		return undefinedLang
+	}
+	return canonicalLanguage(t.Props.Lang)
+}
+
+// fileLanguages lists the languages of the subtitle (and, if requested, audio) tracks
+func fileLanguages(tracks []Track, audio bool) []string {
+	var langs []string
+	for _, t := range tracks {
+		if t.Type != "subtitles" && !(audio && t.Type == "audio") {
+			continue
+		}
+		if lang := trackLanguage(t); !slices.Contains(langs, lang) {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
@@ -0,0 +1,130 @@
+package mkv
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalTag(t *testing.T) {
+	tests := map[string]string{
+		"ita":        "ita",
+		"pt-br":      "pt-BR",
+		"ES-419":     "es-419",
+		"zh-hant-tw": "zh-Hant-TW",
+		"":           "",
+	}
+	for input, expected := range tests {
+		if got := CanonicalTag(input); got != expected {
+			t.Errorf("CanonicalTag(%q) = %q; want %q", input, got, expected)
+		}
+	}
+}
+
+func TestTrackMatchesLanguage(t *testing.T) {
+	var info Info
+	data := `{"tracks": [
+		{"id": 1, "type": "subtitles", "properties": {"language": "por", "language_ietf": "pt-BR"}},
+		{"id": 2, "type": "subtitles", "properties": {"language": "por", "language_ietf": "pt-PT"}},
+		{"id": 3, "type": "subtitles", "properties": {"language": "ita"}}
+	]}`
+	if err := json.Unmarshal([]byte(data), &info); err != nil {
+		t.Fatalf("Failed to unmarshal info: %v", err)
+	}
+
+	tests := []struct {
+		lang     string
+		expected []int
+	}{
+		{"pt-BR", []int{1}},
+		{"por-BR", []int{1}},
+		{"pt-PT", []int{2}},
+		{"por", []int{1, 2}},
+		{"ita", []int{3}},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, track := range info.Tracks {
+			if trackMatchesLanguage(track, tt.lang) {
+				got = append(got, track.ID)
+			}
+		}
+		if len(got) != len(tt.expected) {
+			t.Errorf("trackMatchesLanguage(%q) matched %v; want %v", tt.lang, got, tt.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("trackMatchesLanguage(%q) matched %v; want %v", tt.lang, got, tt.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := map[string]string{
+		"ita":     "ita",
+		"it":      "ita",
+		"Italian": "ita",
+		"fra":     "fre",
+		"fre":     "fre",
+		"deu":     "ger",
+		"de":      "ger",
+		"pt-br":   "pt-BR",
+		"por-BR":  "pt-BR",
+		"ger-ch":  "de-CH",
+		"es-419":  "es-419",
+		"":        "",
+		// Codes win over names: "ga" is Irish, not the Ga language
+		"ga":      "gle",
+		"gaa":     "gaa",
+		"irish":   "gle",
+		"greek":   "gre",
+		"tonga":   "ton",
+		"persian": "per",
+	}
+	for input, expected := range tests {
+		got, err := NormalizeLanguage(input)
+		if err != nil || got != expected {
+			t.Errorf("NormalizeLanguage(%q) = %q, %v; want %q", input, got, err, expected)
+		}
+	}
+
+	_, err := NormalizeLanguage("itl")
+	if err == nil || !strings.Contains(err.Error(), "did you mean") || !strings.Contains(err.Error(), "ita") {
+		t.Errorf("NormalizeLanguage(\"itl\") error = %v; want a suggestion of ita", err)
+	}
+	if _, err := NormalizeLanguage("xx-BR"); err == nil {
+		t.Errorf("NormalizeLanguage(\"xx-BR\") expected an error")
+	}
+}
+
+func TestTrackMatchesEquivalentCodes(t *testing.T) {
+	var track Track
+	track.Props.Lang = "deu"
+	if !trackMatchesLanguage(track, "ger") {
+		t.Errorf("a deu track should match ger")
+	}
+}
+
+func TestFileLanguages(t *testing.T) {
+	var info Info
+	data := `{"tracks": [
+		{"id": 0, "type": "video", "properties": {"language": "jpn"}},
+		{"id": 1, "type": "audio", "properties": {"language": "jpn"}},
+		{"id": 2, "type": "subtitles", "properties": {"language": "por", "language_ietf": "pt-BR"}},
+		{"id": 3, "type": "subtitles", "properties": {"language": "deu", "language_ietf": "de"}},
+		{"id": 4, "type": "subtitles", "properties": {"language": "und"}}
+	]}`
+	if err := json.Unmarshal([]byte(data), &info); err != nil {
+		t.Fatalf("Failed to unmarshal info: %v", err)
+	}
+
+	if got := strings.Join(fileLanguages(info.Tracks, false), ","); got != "pt-BR,ger,und" {
+		t.Errorf("fileLanguages(subtitles) = %q; want pt-BR,ger,und", got)
+	}
+	if got := strings.Join(fileLanguages(info.Tracks, true), ","); got != "jpn,pt-BR,ger,und" {
+		t.Errorf("fileLanguages(audio) = %q; want jpn,pt-BR,ger,und", got)
+	}
+}
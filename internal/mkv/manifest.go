@@ -0,0 +1,269 @@
+package mkv
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PairingEntry maps a video to the external files merged into it
+type PairingEntry struct {
+	Video     string   `json:"video"`
+	Subtitles []string `json:"subtitles,omitempty"`
+	Audio     []string `json:"audio,omitempty"`
+	Fonts     []string `json:"fonts,omitempty"`
+	Chapters  string   `json:"chapters,omitempty"`
+	SubDelay  *int64   `json:"sub_delay,omitempty"` // Overrides --sub-delay for this video
+	SubFPS    string   `json:"sub_fps,omitempty"`   // Overrides --sub-fps for this video
+}
+
+// Manifest is an explicit video ↔ subtitle ↔ audio ↔ fonts pairing, stored as JSON or CSV.
+// Relative paths are resolved against the directory of the manifest file.
+type Manifest struct {
+	Entries []PairingEntry `json:"entries"`
+	baseDir string
+}
+
+// csvHeader is the column layout of CSV manifests; multiple files in a cell are separated by ";"
+var csvHeader = []string{"video", "subtitles", "audio", "fonts", "chapters", "sub_delay", "sub_fps"}
+
+// LoadManifest reads a pairing manifest (.json or .csv)
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pairing manifest: %v", err)
+	}
+
+	m := &Manifest{}
+	if isCSVManifest(path) {
+		m.Entries, err = parseCSVManifest(string(data))
+	} else {
+		err = json.Unmarshal(data, m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pairing manifest %s: %v", path, err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	m.baseDir = filepath.Dir(absPath)
+
+	for i, e := range m.Entries {
+		if e.Video == "" {
+			return nil, fmt.Errorf("pairing manifest %s: entry %d has no video", path, i+1)
+		}
+		if err := ValidateSubFPS(e.SubFPS); err != nil {
+			return nil, fmt.Errorf("pairing manifest %s: entry %d: invalid sub_fps: %v", path, i+1, err)
+		}
+	}
+	return m, nil
+}
+
+// Lookup returns the entry for a video with all paths made absolute.
+// Entries are matched by resolved path, or by file name when the entry has no directory.
+func (m *Manifest) Lookup(video string) (PairingEntry, bool) {
+	absVideo, _ := filepath.Abs(video)
+	for _, e := range m.Entries {
+		matched := m.resolve(e.Video) == absVideo
+		if !matched && !strings.ContainsAny(e.Video, `/\`) {
+			matched = e.Video == filepath.Base(video)
+		}
+		if matched {
+			return PairingEntry{
+				Video:     absVideo,
+				Subtitles: m.resolveAll(e.Subtitles),
+				Audio:     m.resolveAll(e.Audio),
+				Fonts:     m.resolveAll(e.Fonts),
+				Chapters:  m.resolve(e.Chapters),
+				SubDelay:  e.SubDelay,
+				SubFPS:    e.SubFPS,
+			}, true
+		}
+	}
+	return PairingEntry{}, false
+}
+
+// hasFiles reports whether the entry pairs files with its video, rather than only
+// adjusting the timing of the discovered subtitles
+func (e PairingEntry) hasFiles() bool {
+	return len(e.Subtitles) > 0 || len(e.Audio) > 0 || len(e.Fonts) > 0 || e.Chapters != ""
+}
+
+// pairsFiles reports whether any entry pairs files. Manifests that only adjust timing
+// leave unlisted videos to automatic discovery; otherwise unlisted videos are skipped.
+func (m *Manifest) pairsFiles() bool {
+	for _, e := range m.Entries {
+		if e.hasFiles() {
+			return true
+		}
+	}
+	return false
+}
+
+// Write saves the manifest to path (.json or .csv), storing paths relative to its directory
+func (m *Manifest) Write(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(absPath)
+
+	rel := &Manifest{}
+	for _, e := range m.Entries {
+		rel.Entries = append(rel.Entries, PairingEntry{
+			Video:     relativeTo(dir, e.Video),
+			Subtitles: relativeAll(dir, e.Subtitles),
+			Audio:     relativeAll(dir, e.Audio),
+			Fonts:     relativeAll(dir, e.Fonts),
+			Chapters:  relativeTo(dir, e.Chapters),
+			SubDelay:  e.SubDelay,
+			SubFPS:    e.SubFPS,
+		})
+	}
+
+	var data []byte
+	if isCSVManifest(path) {
+		data, err = rel.csv()
+	} else {
+		data, err = json.MarshalIndent(rel, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode pairing manifest: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write pairing manifest: %v", err)
+	}
+	return nil
+}
+
+func (m *Manifest) csv() ([]byte, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, e := range m.Entries {
+		delay := ""
+		if e.SubDelay != nil {
+			delay = strconv.FormatInt(*e.SubDelay, 10)
+		}
+		row := []string{e.Video, strings.Join(e.Subtitles, ";"), strings.Join(e.Audio, ";"), strings.Join(e.Fonts, ";"), e.Chapters, delay, e.SubFPS}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return []byte(sb.String()), w.Error()
+}
+
+func parseCSVManifest(data string) ([]PairingEntry, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []PairingEntry
+	for i, rec := range records {
+		if i == 0 && len(rec) > 0 && strings.EqualFold(rec[0], csvHeader[0]) {
+			continue // header row
+		}
+		for len(rec) < len(csvHeader) {
+			rec = append(rec, "")
+		}
+		entry := PairingEntry{
+			Video:     strings.TrimSpace(rec[0]),
+			Subtitles: splitList(rec[1]),
+			Audio:     splitList(rec[2]),
+			Fonts:     splitList(rec[3]),
+			Chapters:  strings.TrimSpace(rec[4]),
+			SubFPS:    strings.TrimSpace(rec[6]),
+		}
+		if delay := strings.TrimSpace(rec[5]); delay != "" {
+			ms, err := strconv.ParseInt(delay, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid sub_delay %q", i+1, delay)
+			}
+			entry.SubDelay = &ms
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (m *Manifest) resolve(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(m.baseDir, p)
+}
+
+func (m *Manifest) resolveAll(paths []string) []string {
+	var out []string
+	for _, p := range paths {
+		out = append(out, m.resolve(p))
+	}
+	return out
+}
+
+func relativeTo(dir, p string) string {
+	if p == "" {
+		return ""
+	}
+	if rel, err := filepath.Rel(dir, p); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+func relativeAll(dir string, paths []string) []string {
+	var out []string
+	for _, p := range paths {
+		out = append(out, relativeTo(dir, p))
+	}
+	return out
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func isCSVManifest(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".csv")
+}
+
+var (
+	manifestCacheMu sync.Mutex
+	manifestCache   = map[string]*Manifest{}
+)
+
+// cachedManifest loads a manifest once and shares it between workers
+func cachedManifest(path string) (*Manifest, error) {
+	manifestCacheMu.Lock()
+	defer manifestCacheMu.Unlock()
+
+	if m, ok := manifestCache[path]; ok {
+		return m, nil
+	}
+	m, err := LoadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	manifestCache[path] = m
+	return m, nil
+}
@@ -0,0 +1,129 @@
+package mkv
+
+import (
+	"mkvtea/internal/config"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	delay := int64(-250)
+	for _, name := range []string{"pairing.json", "pairing.csv"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			manifest := &Manifest{Entries: []PairingEntry{
+				{
+					Video:     filepath.Join(dir, "Show - Movie.mkv"),
+					Subtitles: []string{filepath.Join(dir, "subs", "movie_ita.ass"), filepath.Join(dir, "subs", "movie_ita_forced.ass")},
+					Fonts:     []string{filepath.Join(dir, "fonts", "Arial.ttf")},
+					Chapters:  filepath.Join(dir, "chapters", "movie.xml"),
+					SubDelay:  &delay,
+					SubFPS:    "25:23.976",
+				},
+				{
+					Video: filepath.Join(dir, "Season 1", "Show - OVA.mkv"),
+					Audio: []string{filepath.Join(dir, "audio", "ova_ita.ac3")},
+				},
+			}}
+
+			path := filepath.Join(dir, name)
+			if err := manifest.Write(path); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			loaded, err := LoadManifest(path)
+			if err != nil {
+				t.Fatalf("LoadManifest failed: %v", err)
+			}
+
+			for _, want := range manifest.Entries {
+				got, ok := loaded.Lookup(want.Video)
+				if !ok {
+					t.Fatalf("Lookup(%q) found no entry", want.Video)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Lookup(%q) =\n  %+v\nwant\n  %+v", want.Video, got, want)
+				}
+			}
+
+			if _, ok := loaded.Lookup(filepath.Join(dir, "Other.mkv")); ok {
+				t.Errorf("Lookup of an unlisted video should fail")
+			}
+		})
+	}
+}
+
+func TestManifestLookupByFileName(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pairing.csv")
+	csv := "video,subtitles,audio,fonts\nShow - Movie.mkv,subs/a.ass; subs/b.srt,,\n"
+	if err := os.WriteFile(path, []byte(csv), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	m, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+
+	// Entries without a directory match videos anywhere in the library
+	got, ok := m.Lookup("/library/Season 1/Show - Movie.mkv")
+	if !ok {
+		t.Fatalf("Lookup by file name failed")
+	}
+	want := []string{filepath.Join(dir, "subs", "a.ass"), filepath.Join(dir, "subs", "b.srt")}
+	if !reflect.DeepEqual(got.Subtitles, want) {
+		t.Errorf("Subtitles = %v; want %v", got.Subtitles, want)
+	}
+}
+
+func TestLoadManifestInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pairing.json")
+	if err := os.WriteFile(path, []byte(`{"entries": [{"subtitles": ["a.ass"]}]}`), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if _, err := LoadManifest(path); err == nil {
+		t.Errorf("Expected an error for an entry without video")
+	}
+}
+
+func TestMergeAssetsTimingOnlyManifest(t *testing.T) {
+	dir := t.TempDir()
+	subsDir := filepath.Join(dir, "subs", "ita")
+	if err := os.MkdirAll(subsDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	for _, name := range []string{"05_ita.ass", "06_ita.ass"} {
+		if err := os.WriteFile(filepath.Join(subsDir, name), []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	// Only episode 05 is out of sync; episode 06 isn't listed at all
+	path := filepath.Join(dir, "pairing.json")
+	if err := os.WriteFile(path, []byte(`{"entries": [{"video": "Show - 05.mkv", "sub_delay": -500, "sub_fps": "25:23.976"}]}`), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	cfg := config.Config{Dir: dir, Lang: "ita", Match: "auto", Pairing: path}
+
+	assets, _, err := mergeAssets(filepath.Join(dir, "Show - 05.mkv"), cfg)
+	if err != nil {
+		t.Fatalf("mergeAssets failed: %v", err)
+	}
+	if len(assets.Subtitles) != 1 || filepath.Base(assets.Subtitles[0]) != "05_ita.ass" {
+		t.Errorf("Subtitles = %v; want the discovered 05_ita.ass", assets.Subtitles)
+	}
+	if assets.SubDelay == nil || *assets.SubDelay != -500 || assets.SubFPS != "25:23.976" {
+		t.Errorf("timing = %v, %q; want the manifest's", assets.SubDelay, assets.SubFPS)
+	}
+
+	assets, _, err = mergeAssets(filepath.Join(dir, "Show - 06.mkv"), cfg)
+	if err != nil {
+		t.Fatalf("mergeAssets of an unlisted video failed: %v", err)
+	}
+	if len(assets.Subtitles) != 1 || assets.SubDelay != nil {
+		t.Errorf("unlisted video = %+v; want discovery without timing", assets)
+	}
+}
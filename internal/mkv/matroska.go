@@ -0,0 +1,638 @@
+package mkv
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// Matroska element IDs read by readMatroskaInfo
+const (
+	idEBML                = 0x1A45DFA3
+	idDocType             = 0x4282
+	idSegment             = 0x18538067
+	idSeekHead            = 0x114D9B74
+	idSeek                = 0x4DBB
+	idSeekID              = 0x53AB
+	idSeekPosition        = 0x53AC
+	idSegmentInfo         = 0x1549A966
+	idTimestampScale      = 0x2AD7B1
+	idDuration            = 0x4489
+	idTitle               = 0x7BA9
+	idTracks              = 0x1654AE6B
+	idTrackEntry          = 0xAE
+	idTrackNumber         = 0xD7
+	idTrackUID            = 0x73C5
+	idTrackType           = 0x83
+	idFlagDefault         = 0x88
+	idFlagForced          = 0x55AA
+	idFlagHearingImpaired = 0x55AB
+	idCodecDelay          = 0x56AA
+	idName                = 0x536E
+	idLanguage            = 0x22B59C
+	idLanguageBCP47       = 0x22B59D
+	idCodecID             = 0x86
+	idCodecPrivate        = 0x63A2
+	idDefaultDuration     = 0x23E383
+	idVideo               = 0xE0
+	idPixelWidth          = 0xB0
+	idPixelHeight         = 0xBA
+	idAudio               = 0xE1
+	idSamplingFrequency   = 0xB5
+	idChannels            = 0x9F
+	idContentEncodings    = 0x6D80
+	idContentEncoding     = 0x6240
+	idContentCompression  = 0x5034
+	idContentCompAlgo     = 0x4254
+	idContentCompSettings = 0x4255
+	idContentEncryption   = 0x5035
+	idCluster             = 0x1F43B675
+	idAttachments         = 0x1941A469
+	idAttachedFile        = 0x61A7
+	idFileName            = 0x466E
+	idFileMimeType        = 0x4660
+	idFileData            = 0x465C
+	idChapters            = 0x1043A770
+	idEditionEntry        = 0x45B9
+	idChapterAtom         = 0xB6
+	idTags                = 0x1254C367
+	idTag                 = 0x7373
+	idTargets             = 0x63C0
+	idTagTrackUID         = 0x63C5
+)
+
+// trackTypes maps Matroska TrackType values to the names used by mkvmerge
+var trackTypes = map[uint64]string{
+	1:    "video",
+	2:    "audio",
+	0x11: "subtitles",
+	0x12: "buttons",
+}
+
+// codecNames maps Matroska codec IDs to the codec names reported by mkvmerge.
+// IDs with a suffix (A_AAC/MPEG4/LC, A_PCM/INT/LIT) are matched by prefix.
+var codecNames = []struct{ prefix, name string }{
+	{"V_MPEG4/ISO/AVC", "AVC/H.264/MPEG-4p10"},
+	{"V_MPEGH/ISO/HEVC", "HEVC/H.265/MPEG-H"},
+	{"V_AV1", "AV1"},
+	{"V_VP8", "VP8"},
+	{"V_VP9", "VP9"},
+	{"V_MPEG1", "MPEG-1/2"},
+	{"V_MPEG2", "MPEG-1/2"},
+	{"A_AAC", "AAC"},
+	{"A_EAC3", "E-AC-3"},
+	{"A_AC3", "AC-3"},
+	{"A_DTS", "DTS"},
+	{"A_FLAC", "FLAC"},
+	{"A_OPUS", "Opus"},
+	{"A_VORBIS", "Vorbis"},
+	{"A_MPEG/L3", "MP3"},
+	{"A_MPEG/L2", "MP2"},
+	{"A_PCM", "PCM"},
+	{"A_TRUEHD", "TrueHD"},
+	{"S_TEXT/UTF8", "SubRip/SRT"},
+	{"S_TEXT/ASCII", "SubRip/SRT"},
+	{"S_TEXT/ASS", "SubStationAlpha"},
+	{"S_TEXT/SSA", "SubStationAlpha"},
+	{"S_ASS", "SubStationAlpha"},
+	{"S_SSA", "SubStationAlpha"},
+	{"S_TEXT/WEBVTT", "WebVTT"},
+	{"S_TEXT/USF", "USF"},
+	{"S_HDMV/PGS", "HDMV PGS"},
+	{"S_HDMV/TEXTST", "HDMV TextST"},
+	{"S_VOBSUB", "VobSub"},
+}
+
+func codecName(codecID string) string {
+	for _, c := range codecNames {
+		if strings.HasPrefix(codecID, c.prefix) {
+			return c.name
+		}
+	}
+	return codecID
+}
+
+// readMatroskaInfo reads the metadata of a Matroska/WebM file without mkvmerge.
+// Only the header elements are read: clusters are never scanned, and elements stored
+// after them (tags, attachments) are reached through the SeekHead.
+func readMatroskaInfo(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m, err := readMatroska(f)
+	if err != nil {
+		return nil, err
+	}
+	return m.info, nil
+}
+
+// readMatroska parses the EBML header and the segment's top-level metadata
+func readMatroska(f io.ReadSeeker) (*matroskaInfo, error) {
+	r := &ebmlReader{r: f}
+	header, err := r.next()
+	if err != nil || header.id != idEBML {
+		return nil, fmt.Errorf("not an EBML file")
+	}
+	if err := checkDocType(r, header); err != nil {
+		return nil, err
+	}
+	if err := r.seek(header.end()); err != nil {
+		return nil, err
+	}
+
+	segment, err := r.next()
+	if err != nil || segment.id != idSegment {
+		return nil, fmt.Errorf("missing Matroska segment")
+	}
+
+	m := &matroskaInfo{info: &Info{Container: Container{Type: "Matroska"}}, reader: r, segment: segment}
+	if err := m.read(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func checkDocType(r *ebmlReader, header ebmlElement) error {
+	data, err := r.body(header)
+	if err != nil {
+		return err
+	}
+	nodes, err := parseChildren(data)
+	if err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		if n.id == idDocType {
+			switch docType := ebmlString(n.data); docType {
+			case "matroska", "webm":
+				return nil
+			default:
+				return fmt.Errorf("unsupported EBML document type %q", docType)
+			}
+		}
+	}
+	return fmt.Errorf("missing EBML document type")
+}
+
+// matroskaInfo accumulates the top-level elements of a segment into an Info,
+// along with what is needed to read the blocks of its tracks
+type matroskaInfo struct {
+	info    *Info
+	reader  *ebmlReader
+	segment ebmlElement
+	seen    map[int64]bool // Offsets of the top-level elements already parsed
+	seeks   []int64        // Offsets announced by SeekHeads
+	trackID map[uint64]int // TrackUID → track ID, for track tags
+
+	scale        uint64                 // Nanoseconds per timestamp unit
+	firstCluster int64                  // Offset of the first cluster, -1 when there is none
+	codecs       map[uint64]*trackCodec // Track number → codec data
+	files        []ebmlElement          // AttachedFile elements, in attachment ID order
+}
+
+// trackCodec holds the track entry fields needed to decode its blocks
+type trackCodec struct {
+	private         []byte
+	defaultDuration uint64 // Nanoseconds, 0 when unset
+	compression     *contentCompression
+}
+
+// contentCompression is the compression applied to every frame of a track
+type contentCompression struct {
+	algo     uint64 // 0 = zlib, 3 = header stripping
+	settings []byte // Stripped header bytes
+}
+
+func (m *matroskaInfo) read() error {
+	m.seen = map[int64]bool{}
+	m.trackID = map[uint64]int{}
+	m.codecs = map[uint64]*trackCodec{}
+	m.scale = 1000000
+	m.firstCluster = -1
+
+	// Read the top-level elements in order until the first cluster
+	if err := m.reader.seek(m.segment.offset); err != nil {
+		return err
+	}
+	for m.segment.size == unknownSize || m.reader.pos < m.segment.end() {
+		start := m.reader.pos
+		el, err := m.reader.next()
+		if err != nil {
+			break // Truncated file: keep what was read
+		}
+		if el.id == idCluster {
+			m.firstCluster = start
+			break
+		}
+		if el.size == unknownSize {
+			break
+		}
+		if err := m.parse(start, el); err != nil {
+			return err
+		}
+		if err := m.reader.skip(el); err != nil {
+			return err
+		}
+	}
+
+	// Follow the SeekHead to the elements written after the clusters
+	for i := 0; i < len(m.seeks); i++ {
+		start := m.seeks[i]
+		if m.seen[start] {
+			continue
+		}
+		if err := m.reader.seek(start); err != nil {
+			return err
+		}
+		el, err := m.reader.next()
+		if err != nil {
+			return fmt.Errorf("invalid SeekHead entry at offset %d: %v", start, err)
+		}
+		if err := m.parse(start, el); err != nil {
+			return err
+		}
+	}
+
+	if len(m.info.Tracks) == 0 {
+		return fmt.Errorf("no Matroska tracks found")
+	}
+	return nil
+}
+
+// parse handles a top-level element starting at offset start
+func (m *matroskaInfo) parse(start int64, el ebmlElement) error {
+	if m.seen[start] {
+		return nil
+	}
+	m.seen[start] = true
+
+	switch el.id {
+	case idSeekHead:
+		return m.parseSeekHead(el)
+	case idSegmentInfo:
+		return m.parseSegmentInfo(el)
+	case idTracks:
+		return m.parseTracks(el)
+	case idAttachments:
+		return m.parseAttachments(el)
+	case idChapters:
+		return m.parseChapters(el)
+	case idTags:
+		return m.parseTags(el)
+	}
+	return nil
+}
+
+func (m *matroskaInfo) parseSeekHead(el ebmlElement) error {
+	data, err := m.reader.body(el)
+	if err != nil {
+		return err
+	}
+	seeks, err := parseChildren(data)
+	if err != nil {
+		return err
+	}
+	for _, seek := range seeks {
+		if seek.id != idSeek {
+			continue
+		}
+		fields, err := parseChildren(seek.data)
+		if err != nil {
+			return err
+		}
+		var target uint64
+		position := int64(-1)
+		for _, f := range fields {
+			switch f.id {
+			case idSeekID:
+				target = ebmlUint(f.data)
+			case idSeekPosition:
+				position = int64(ebmlUint(f.data))
+			}
+		}
+		switch target {
+		case idSeekHead, idSegmentInfo, idTracks, idAttachments, idChapters, idTags:
+			if position >= 0 {
+				m.seeks = append(m.seeks, m.segment.offset+position)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *matroskaInfo) parseSegmentInfo(el ebmlElement) error {
+	data, err := m.reader.body(el)
+	if err != nil {
+		return err
+	}
+	fields, err := parseChildren(data)
+	if err != nil {
+		return err
+	}
+	var duration float64
+	for _, f := range fields {
+		switch f.id {
+		case idTimestampScale:
+			m.scale = ebmlUint(f.data)
+		case idDuration:
+			duration = ebmlFloat(f.data)
+		case idTitle:
+			m.info.Container.Properties.Title = ebmlString(f.data)
+		}
+	}
+	m.info.Container.Properties.Duration = int64(math.Round(duration * float64(m.scale)))
+	return nil
+}
+
+func (m *matroskaInfo) parseTracks(el ebmlElement) error {
+	data, err := m.reader.body(el)
+	if err != nil {
+		return err
+	}
+	entries, err := parseChildren(data)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.id != idTrackEntry {
+			continue
+		}
+		fields, err := parseChildren(entry.data)
+		if err != nil {
+			return err
+		}
+
+		t := Track{ID: len(m.info.Tracks)}
+		t.Props.Lang = "eng" // Matroska default when the element is absent
+		t.Props.Default = true
+		codec := &trackCodec{}
+		for _, f := range fields {
+			switch f.id {
+			case idCodecPrivate:
+				codec.private = f.data
+				t.Props.CodecPrivateLength = len(f.data)
+				t.Props.CodecPrivateData = hex.EncodeToString(f.data)
+			case idVideo:
+				if err := parseVideo(&t, f.data); err != nil {
+					return err
+				}
+			case idAudio:
+				if err := parseAudio(&t, f.data); err != nil {
+					return err
+				}
+			case idDefaultDuration:
+				codec.defaultDuration = ebmlUint(f.data)
+			case idContentEncodings:
+				if codec.compression, err = parseContentEncodings(f.data); err != nil {
+					return err
+				}
+			case idTrackNumber:
+				t.Props.Number = ebmlUint(f.data)
+			case idTrackUID:
+				t.Props.UID = ebmlUint(f.data)
+			case idTrackType:
+				t.Type = trackTypes[ebmlUint(f.data)]
+			case idFlagDefault:
+				t.Props.Default = ebmlUint(f.data) == 1
+			case idFlagForced:
+				t.Props.Forced = ebmlUint(f.data) == 1
+			case idFlagHearingImpaired:
+				t.Props.HearingImpaired = ebmlUint(f.data) == 1
+			case idCodecDelay:
+				t.Props.CodecDelay = int64(ebmlUint(f.data))
+			case idName:
+				t.Props.TrackName = ebmlString(f.data)
+			case idLanguage:
+				t.Props.Lang = ebmlString(f.data)
+			case idLanguageBCP47:
+				t.Props.LangIETF = ebmlString(f.data)
+			case idCodecID:
+				t.Props.CodecID = ebmlString(f.data)
+			}
+		}
+		t.Codec = codecName(t.Props.CodecID)
+		if t.Props.LangIETF == "" {
+			t.Props.LangIETF = ietfFromISO639(t.Props.Lang)
+		} else {
+			// LanguageBCP47 overrides the legacy Language element
+			t.Props.Lang = iso639FromIETF(t.Props.LangIETF)
+		}
+		m.trackID[t.Props.UID] = t.ID
+		m.codecs[t.Props.Number] = codec
+		m.info.Tracks = append(m.info.Tracks, t)
+	}
+	return nil
+}
+
+// parseVideo reads the pixel dimensions of a video track
+func parseVideo(t *Track, data []byte) error {
+	fields, err := parseChildren(data)
+	if err != nil {
+		return err
+	}
+	var width, height uint64
+	for _, f := range fields {
+		switch f.id {
+		case idPixelWidth:
+			width = ebmlUint(f.data)
+		case idPixelHeight:
+			height = ebmlUint(f.data)
+		}
+	}
+	t.Props.PixelDimensions = fmt.Sprintf("%dx%d", width, height)
+	return nil
+}
+
+// parseAudio reads the channels and sampling frequency of an audio track
+func parseAudio(t *Track, data []byte) error {
+	fields, err := parseChildren(data)
+	if err != nil {
+		return err
+	}
+	// Matroska defaults when the elements are absent
+	t.Props.AudioChannels, t.Props.AudioSamplingFrequency = 1, 8000
+	for _, f := range fields {
+		switch f.id {
+		case idChannels:
+			t.Props.AudioChannels = int(ebmlUint(f.data))
+		case idSamplingFrequency:
+			t.Props.AudioSamplingFrequency = int(math.Round(ebmlFloat(f.data)))
+		}
+	}
+	return nil
+}
+
+// parseAttachments lists the attached files without reading their data
+func (m *matroskaInfo) parseAttachments(el ebmlElement) error {
+	return m.reader.children(el, func(file ebmlElement) error {
+		if file.id != idAttachedFile {
+			return nil
+		}
+		a := Attachment{ID: len(m.info.Attachments) + 1}
+		m.files = append(m.files, file)
+		err := m.reader.children(file, func(f ebmlElement) error {
+			switch f.id {
+			case idFileName, idFileMimeType:
+				data, err := m.reader.body(f)
+				if err != nil {
+					return err
+				}
+				if f.id == idFileName {
+					a.FileName = ebmlString(data)
+				} else {
+					a.ContentType = ebmlString(data)
+				}
+			case idFileData:
+				a.Size = f.size
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		m.info.Attachments = append(m.info.Attachments, a)
+		return nil
+	})
+}
+
+func (m *matroskaInfo) parseChapters(el ebmlElement) error {
+	data, err := m.reader.body(el)
+	if err != nil {
+		return err
+	}
+	editions, err := parseChildren(data)
+	if err != nil {
+		return err
+	}
+	for _, edition := range editions {
+		if edition.id != idEditionEntry {
+			continue
+		}
+		atoms, err := parseChildren(edition.data)
+		if err != nil {
+			return err
+		}
+		entries := 0
+		for _, atom := range atoms {
+			if atom.id == idChapterAtom {
+				entries++
+			}
+		}
+		m.info.Chapters = append(m.info.Chapters, Chapter{NumEntries: entries})
+	}
+	return nil
+}
+
+// parseTags counts the global tags and the tags of each track
+func (m *matroskaInfo) parseTags(el ebmlElement) error {
+	data, err := m.reader.body(el)
+	if err != nil {
+		return err
+	}
+	tags, err := parseChildren(data)
+	if err != nil {
+		return err
+	}
+
+	global := 0
+	perTrack := map[int]int{}
+	var order []int
+	for _, tag := range tags {
+		if tag.id != idTag {
+			continue
+		}
+		trackUID, err := tagTrackUID(tag.data)
+		if err != nil {
+			return err
+		}
+		id, ok := m.trackID[trackUID]
+		if trackUID == 0 || !ok {
+			global++
+			continue
+		}
+		if perTrack[id] == 0 {
+			order = append(order, id)
+		}
+		perTrack[id]++
+	}
+
+	if global > 0 {
+		m.info.GlobalTags = append(m.info.GlobalTags, TagSummary{NumEntries: global})
+	}
+	for _, id := range order {
+		m.info.TrackTags = append(m.info.TrackTags, TagSummary{NumEntries: perTrack[id], TrackID: id})
+	}
+	return nil
+}
+
+// tagTrackUID returns the track a tag applies to, 0 for global tags
+func tagTrackUID(tag []byte) (uint64, error) {
+	fields, err := parseChildren(tag)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range fields {
+		if f.id != idTargets {
+			continue
+		}
+		targets, err := parseChildren(f.data)
+		if err != nil {
+			return 0, err
+		}
+		for _, t := range targets {
+			if t.id == idTagTrackUID {
+				return ebmlUint(t.data), nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+// parseContentEncodings returns the frame compression of a track. Encrypted tracks
+// and chained encodings are reported as errors: they can only be read by mkvextract.
+func parseContentEncodings(data []byte) (*contentCompression, error) {
+	encodings, err := parseChildren(data)
+	if err != nil {
+		return nil, err
+	}
+	var compression *contentCompression
+	for _, enc := range encodings {
+		if enc.id != idContentEncoding {
+			continue
+		}
+		if compression != nil {
+			return nil, fmt.Errorf("multiple content encodings are not supported")
+		}
+		fields, err := parseChildren(enc.data)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			switch f.id {
+			case idContentEncryption:
+				return nil, fmt.Errorf("encrypted tracks are not supported")
+			case idContentCompression:
+				compression = &contentCompression{}
+				settings, err := parseChildren(f.data)
+				if err != nil {
+					return nil, err
+				}
+				for _, c := range settings {
+					switch c.id {
+					case idContentCompAlgo:
+						compression.algo = ebmlUint(c.data)
+					case idContentCompSettings:
+						compression.settings = c.data
+					}
+				}
+			}
+		}
+	}
+	return compression, nil
+}
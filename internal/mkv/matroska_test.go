@@ -0,0 +1,184 @@
+package mkv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ebmlEl encodes an element with an eight-byte size, which keeps offsets predictable
+func ebmlEl(id uint32, children ...[]byte) []byte {
+	var buf bytes.Buffer
+	idBytes := []byte{byte(id >> 24), byte(id >> 16), byte(id >> 8), byte(id)}
+	for len(idBytes) > 1 && idBytes[0] == 0 {
+		idBytes = idBytes[1:]
+	}
+	buf.Write(idBytes)
+	body := bytes.Join(children, nil)
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(body)))
+	size[0] = 0x01
+	buf.Write(size)
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+func ebmlU(id uint32, v uint64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, v)
+	return ebmlEl(id, data)
+}
+
+func ebmlS(id uint32, s string) []byte {
+	return ebmlEl(id, []byte(s))
+}
+
+func ebmlF(id uint32, f float64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, math.Float64bits(f))
+	return ebmlEl(id, data)
+}
+
+func ebmlSeek(id uint32, position int) []byte {
+	return ebmlEl(idSeek, ebmlU(idSeekID, uint64(id)), ebmlU(idSeekPosition, uint64(position)))
+}
+
+// buildSampleMKV writes a Matroska file whose attachments and tags are stored after
+// the first cluster, so that they can only be found through the SeekHead
+func buildSampleMKV(t *testing.T) string {
+	info := ebmlEl(idSegmentInfo,
+		ebmlU(idTimestampScale, 1000000),
+		ebmlF(idDuration, 1420000),
+		ebmlS(idTitle, "Show - 05"),
+	)
+	tracks := ebmlEl(idTracks,
+		ebmlEl(idTrackEntry, ebmlU(idTrackNumber, 1), ebmlU(idTrackUID, 101), ebmlU(idTrackType, 1),
+			ebmlS(idCodecID, "V_MPEG4/ISO/AVC"), ebmlS(idLanguage, "und"),
+			ebmlEl(idVideo, ebmlU(idPixelWidth, 1920), ebmlU(idPixelHeight, 1080))),
+		ebmlEl(idTrackEntry, ebmlU(idTrackNumber, 2), ebmlU(idTrackUID, 102), ebmlU(idTrackType, 2),
+			ebmlS(idCodecID, "A_AAC"), ebmlS(idLanguage, "jpn"), ebmlU(idCodecDelay, 6500000),
+			ebmlS(idCodecPrivate, "\x11\x90"), ebmlEl(idAudio, ebmlF(idSamplingFrequency, 48000), ebmlU(idChannels, 2))),
+		ebmlEl(idTrackEntry, ebmlU(idTrackNumber, 3), ebmlU(idTrackUID, 103), ebmlU(idTrackType, 0x11),
+			ebmlS(idCodecID, "S_TEXT/ASS"), ebmlS(idLanguage, "ita"), ebmlS(idName, "Signs"), ebmlU(idFlagForced, 1),
+			ebmlS(idCodecPrivate, "[Script Info]\nScriptType: v4.00+\n\n[Events]\n"+defaultASSFormat+"\n")),
+		ebmlEl(idTrackEntry, ebmlU(idTrackNumber, 4), ebmlU(idTrackUID, 104), ebmlU(idTrackType, 0x11),
+			ebmlS(idCodecID, "S_TEXT/UTF8"), ebmlS(idLanguage, "por"), ebmlS(idLanguageBCP47, "pt-BR"),
+			ebmlU(idFlagDefault, 0), ebmlU(idFlagHearingImpaired, 1)),
+		ebmlEl(idTrackEntry, ebmlU(idTrackNumber, 5), ebmlU(idTrackUID, 105), ebmlU(idTrackType, 0x11),
+			ebmlS(idCodecID, "S_HDMV/PGS")),
+	)
+	chapters := ebmlEl(idChapters, ebmlEl(idEditionEntry,
+		ebmlEl(idChapterAtom), ebmlEl(idChapterAtom), ebmlEl(idChapterAtom)))
+	cluster := ebmlEl(idCluster, ebmlU(idClusterTimestamp, 0), ebmlEl(idSimpleBlock, []byte{0x81, 0, 0, 0x80, 0xFF, 0xFF}))
+	attachments := ebmlEl(idAttachments, ebmlEl(idAttachedFile,
+		ebmlS(idFileName, "Roboto.ttf"), ebmlS(idFileMimeType, "font/ttf"), ebmlEl(idFileData, make([]byte, 1000))))
+	tags := ebmlEl(idTags,
+		ebmlEl(idTag, ebmlEl(idTargets)),
+		ebmlEl(idTag, ebmlEl(idTargets, ebmlU(idTagTrackUID, 102))),
+		ebmlEl(idTag, ebmlEl(idTargets, ebmlU(idTagTrackUID, 102))),
+	)
+
+	// The SeekHead has a fixed size, so it can be sized before the positions are known
+	seekHeadSize := len(ebmlEl(idSeekHead, ebmlSeek(idAttachments, 0), ebmlSeek(idTags, 0)))
+	attachmentsPos := seekHeadSize + len(info) + len(tracks) + len(chapters) + len(cluster)
+	tagsPos := attachmentsPos + len(attachments)
+	seekHead := ebmlEl(idSeekHead, ebmlSeek(idAttachments, attachmentsPos), ebmlSeek(idTags, tagsPos))
+
+	file := bytes.Join([][]byte{
+		ebmlEl(idEBML, ebmlS(idDocType, "matroska")),
+		ebmlEl(idSegment, seekHead, info, tracks, chapters, cluster, attachments, tags),
+	}, nil)
+
+	path := filepath.Join(t.TempDir(), "sample.mkv")
+	if err := os.WriteFile(path, file, 0644); err != nil {
+		t.Fatalf("Failed to write sample MKV: %v", err)
+	}
+	return path
+}
+
+func TestReadMatroskaInfo(t *testing.T) {
+	path := buildSampleMKV(t)
+	got, err := readMatroskaInfo(path)
+	if err != nil {
+		t.Fatalf("readMatroskaInfo failed: %v", err)
+	}
+
+	// mkvmerge -J output for the same file
+	data := fixture(t, "sample_mkvmerge.json", filepath.Dir(path), "mkvmerge", "-J", filepath.Base(path))
+	var want Info
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatalf("Failed to unmarshal fixture: %v", err)
+	}
+
+	if !reflect.DeepEqual(got.Container, want.Container) {
+		t.Errorf("container = %+v; want %+v", got.Container, want.Container)
+	}
+	for i := range want.Tracks {
+		if i >= len(got.Tracks) {
+			break
+		}
+		if !reflect.DeepEqual(got.Tracks[i], want.Tracks[i]) {
+			t.Errorf("track %d differs from mkvmerge -J:\n got %+v\nwant %+v", i, got.Tracks[i], want.Tracks[i])
+		}
+	}
+	if len(got.Tracks) != len(want.Tracks) {
+		t.Errorf("got %d tracks; want %d", len(got.Tracks), len(want.Tracks))
+	}
+	for name, pair := range map[string][2]any{
+		"attachments": {got.Attachments, want.Attachments},
+		"chapters":    {got.Chapters, want.Chapters},
+		"global_tags": {got.GlobalTags, want.GlobalTags},
+		"track_tags":  {got.TrackTags, want.TrackTags},
+	} {
+		if !reflect.DeepEqual(pair[0], pair[1]) {
+			t.Errorf("%s = %+v; want %+v", name, pair[0], pair[1])
+		}
+	}
+}
+
+func TestReadMatroskaInfoLanguageBCP47(t *testing.T) {
+	// LanguageBCP47 overrides Language, whose "eng" default no longer applies
+	tracks := ebmlEl(idTracks,
+		ebmlEl(idTrackEntry, ebmlU(idTrackNumber, 1), ebmlU(idTrackUID, 1), ebmlU(idTrackType, 0x11),
+			ebmlS(idCodecID, "S_TEXT/UTF8"), ebmlS(idLanguageBCP47, "es-419")),
+		ebmlEl(idTrackEntry, ebmlU(idTrackNumber, 2), ebmlU(idTrackUID, 2), ebmlU(idTrackType, 0x11),
+			ebmlS(idCodecID, "S_TEXT/UTF8"), ebmlS(idLanguage, "ger"), ebmlS(idLanguageBCP47, "fr-CA")),
+	)
+	path := filepath.Join(t.TempDir(), "bcp47.mkv")
+	file := append(ebmlEl(idEBML, ebmlS(idDocType, "matroska")), ebmlEl(idSegment, tracks)...)
+	if err := os.WriteFile(path, file, 0644); err != nil {
+		t.Fatalf("Failed to write sample MKV: %v", err)
+	}
+
+	info, err := readMatroskaInfo(path)
+	if err != nil {
+		t.Fatalf("readMatroskaInfo failed: %v", err)
+	}
+	for i, want := range []string{"spa", "fre"} {
+		if got := info.Tracks[i].Props.Lang; got != want {
+			t.Errorf("track %d language = %q; want %q", i, got, want)
+		}
+	}
+}
+
+func TestReadMatroskaInfoRejectsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{
+		"text.mkv":      []byte("not a matroska file"),
+		"other.mkv":     ebmlEl(idEBML, ebmlS(idDocType, "other")),
+		"truncated.mkv": ebmlEl(idEBML, ebmlS(idDocType, "matroska"))[:10],
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if _, err := readMatroskaInfo(path); err == nil {
+			t.Errorf("readMatroskaInfo(%s) expected an error", name)
+		}
+	}
+}
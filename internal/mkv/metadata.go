@@ -14,9 +14,24 @@ type Track struct {
 	Type  string `json:"type"`
 	Codec string `json:"codec"`
 	Props struct {
+		Number    uint64 `json:"number"` // Track number used by the blocks of the file
+		UID       uint64 `json:"uid"`
 		Lang      string `json:"language"`
+		LangIETF  string `json:"language_ietf"` // BCP 47 tag (pt-BR, es-419), set by recent mkvmerge
 		TrackName string `json:"track_name"`
+		Default   bool   `json:"default_track"`
 		Forced    bool   `json:"forced_track"`
+		// Track for the hearing impaired (SDH), set by recent mkvmerge
+		HearingImpaired bool   `json:"flag_hearing_impaired"`
+		CodecDelay      int64  `json:"codec_delay"` // Nanoseconds
+		CodecID         string `json:"codec_id"`
+		// Hex-encoded CodecPrivate, e.g. the header of an ASS track
+		CodecPrivateLength int    `json:"codec_private_length"`
+		CodecPrivateData   string `json:"codec_private_data"`
+		PixelDimensions    string `json:"pixel_dimensions"` // Video only, e.g. "1920x1080"
+		// Audio only
+		AudioChannels          int `json:"audio_channels"`
+		AudioSamplingFrequency int `json:"audio_sampling_frequency"`
 	} `json:"properties"`
 }
 
@@ -25,16 +40,40 @@ type Attachment struct {
 	ID          int    `json:"id"`
 	FileName    string `json:"file_name"`
 	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// Chapter represents a chapter edition summary from mkvmerge JSON output
+type Chapter struct {
+	NumEntries int `json:"num_entries"`
+}
+
+// Container holds the segment information of the file
+type Container struct {
+	Type       string `json:"type"`
+	Properties struct {
+		Title    string `json:"title"`
+		Duration int64  `json:"duration"` // Nanoseconds
+	} `json:"properties"`
+}
+
+// TagSummary counts the tags of the file (global_tags) or of one track (track_tags)
+type TagSummary struct {
+	NumEntries int `json:"num_entries"`
+	TrackID    int `json:"track_id"` // Only set for track tags
 }
 
 // Info contains metadata about an MKV file
 type Info struct {
+	Container   Container    `json:"container"`
 	Tracks      []Track      `json:"tracks"`
 	Attachments []Attachment `json:"attachments"`
-	Chapters    []any        `json:"chapters"`
+	Chapters    []Chapter    `json:"chapters"`
+	GlobalTags  []TagSummary `json:"global_tags"`
+	TrackTags   []TagSummary `json:"track_tags"`
 }
 
-// GetInfo analyzes MKV file metadata using mkvmerge
+// GetInfo analyzes MKV file metadata, falling back to mkvmerge -J
 func GetInfo(path string) (*Info, error) {
 	// Verify file exists and is accessible
 	if _, err := os.Stat(path); err != nil {
@@ -44,6 +83,14 @@ func GetInfo(path string) (*Info, error) {
 		return nil, fmt.Errorf("file inaccessible (permissions?): %s", path)
 	}
 
+	// Matroska headers are read natively; mkvmerge handles MP4 and anything the
+	// native reader can't parse
+	if !isMP4(path) {
+		if info, err := readMatroskaInfo(path); err == nil {
+			return info, nil
+		}
+	}
+
 	cmd := exec.Command("mkvmerge", "-J", path)
 	out, err := cmd.Output()
 	if err != nil {
@@ -0,0 +1,44 @@
+package mkv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TempPrefix starts the names of the temporary MKV files of MP4 remuxes
+const TempPrefix = ".mkvtea-"
+
+// isMP4 reports whether a video is an MP4 container, which mkvextract cannot read
+func isMP4(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp4", ".m4v":
+		return true
+	}
+	return false
+}
+
+// remuxArgs builds the mkvmerge call copying the MP4 into a Matroska file. Every track
+// is kept, so track IDs (in file names and sidecars) stay those of the MP4; mov_text
+// (tx3g) subtitles become SRT tracks.
+func remuxArgs(path, out string) []string {
+	return []string{"-o", out, path}
+}
+
+// remuxToMKV copies the tracks of an MP4 file into a temporary MKV, so that the regular
+// extraction can run on it. The file is created in the system temporary directory,
+// never in the library, and the caller removes it.
+func remuxToMKV(path string) (string, error) {
+	tmp, err := os.CreateTemp("", TempPrefix+"*.mkv")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary MKV: %v", err)
+	}
+	tmp.Close()
+
+	if err := execute("mkvmerge", remuxArgs(path, tmp.Name())...); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("MP4 remux failed: %v", err)
+	}
+	return tmp.Name(), nil
+}
@@ -0,0 +1,25 @@
+package mkv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsMP4(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"movie.mp4": true,
+		"movie.M4V": true,
+		"movie.mkv": false,
+	} {
+		if got := isMP4(name); got != expected {
+			t.Errorf("isMP4(%q) = %v; want %v", name, got, expected)
+		}
+	}
+}
+
+func TestRemuxArgs(t *testing.T) {
+	// Video and audio are kept so that track IDs match the MP4
+	if got := strings.Join(remuxArgs("movie.mp4", ".tmp.mkv"), " "); got != "-o .tmp.mkv movie.mp4" {
+		t.Errorf("remuxArgs = %q", got)
+	}
+}
@@ -1,14 +1,283 @@
 package mkv
 
-import "regexp"
+import (
+	"fmt"
+	"mkvtea/internal/config"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EpisodeID identifies an episode parsed from a filename
+type EpisodeID struct {
+	Season   int  // Season number (0 when the filename carries none)
+	Episode  int  // Episode number within the season
+	Absolute int  // Absolute episode number (set when no season is given)
+	Special  bool // OVA/OAD/SP/Special marker
+	Version  int  // Release version (v2, v3...), 0 when absent
+	// Last episode of a multi-episode file (S01E01E02, 01-02), 0 for single episodes
+	EndEpisode int
+}
+
+var (
+	// S01E02, s1e02, S01.E02, S01 E02v2
+	seasonEpisodeRe = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])s(\d{1,2})[ ._-]?e(\d{2,4})(?:v(\d))?`)
+	// Second half of a season episode range: E02, -E02, -02
+	seasonRangeRe = regexp.MustCompile(`(?i)^(?:-?e|-)(\d{2,4})(?:v(\d))?`)
+	// Second half of an absolute episode range: -02, " - 02"
+	absoluteRangeRe = regexp.MustCompile(`^(?:-| - )(\d{2,4})(?:v(\d))?`)
+	// Season given as a word before an absolute number: "Show Season 2 - 05"
+	seasonWordRe = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])season[ ._-]?(\d{1,2})(?:[^a-z0-9]|$)`)
+	// OVA 01, OAD02, SP 03, Special 01v2
+	specialRe = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:ova|oad|sp|special)[ ._-]?(\d{1,2})(?:v(\d))?(?:[^a-z0-9]|$)`)
+	// Runs of digits with an optional version suffix (02, 1089, 07v2)
+	numberRe = regexp.MustCompile(`(\d+)(?:v(\d))?`)
+	// Bracketed tags such as [1080p], [ABCD1234] or (2023)
+	bracketRe = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)`)
+	// A bracket holding nothing but an episode number, e.g. [05] or [1089v2]
+	bracketEpisodeRe = regexp.MustCompile(`^\[(\d{2,4})(?:v(\d))?\]$`)
+)
+
+// ParseEpisode extracts the episode identity from a filename.
+// The boolean result is false when no episode number could be found.
+func ParseEpisode(filename string) (EpisodeID, bool) {
+	// Bracketed CRC32 hashes may contain sequences like "E05" or "SP12"
+	filename = crcRe.ReplaceAllStringFunc(filename, func(tag string) string {
+		return strings.Repeat(" ", len(tag))
+	})
+
+	if loc := seasonEpisodeRe.FindStringSubmatchIndex(filename); loc != nil {
+		m := submatches(filename, loc)
+		ep := EpisodeID{Season: atoi(m[1]), Episode: atoi(m[2]), Version: atoi(m[3])}
+		ep.parseRange(filename[loc[1]:], seasonRangeRe)
+		return ep, true
+	}
+
+	if m := specialRe.FindStringSubmatch(filename); m != nil {
+		ep := EpisodeID{Episode: atoi(m[1]), Special: true, Version: atoi(m[2])}
+		return ep, true
+	}
+
+	// "Season 2" is blanked so that its number is not taken for the episode
+	season := 0
+	if loc := seasonWordRe.FindStringSubmatchIndex(filename); loc != nil {
+		season = atoi(filename[loc[2]:loc[3]])
+		filename = filename[:loc[0]] + strings.Repeat(" ", loc[1]-loc[0]) + filename[loc[1]:]
+	}
+	ep, ok := findAbsoluteNumber(filename)
+	if ok && season > 0 {
+		ep.Season, ep.Absolute = season, 0
+	}
+	return ep, ok
+}
+
+// parseRange completes a multi-episode identity from the text following the first episode
+func (e *EpisodeID) parseRange(rest string, re *regexp.Regexp) {
+	loc := re.FindStringSubmatchIndex(rest)
+	if loc == nil || !isNumberBoundary(rest, loc[1]) {
+		return
+	}
+	m := submatches(rest, loc)
+	end := atoi(m[1])
+	if e.Episode < 1000 && end >= 1900 && end < 2100 {
+		return // "Show - 05 - 2023" is a year, not a range
+	}
+	if end > e.Episode {
+		e.EndEpisode = end
+		if v := atoi(m[2]); v > e.Version {
+			e.Version = v
+		}
+	}
+}
+
+// submatches converts a submatch index slice into strings, "" for unmatched groups
+func submatches(s string, loc []int) []string {
+	m := make([]string, len(loc)/2)
+	for i := range m {
+		if loc[2*i] >= 0 {
+			m[i] = s[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return m
+}
+
+// findAbsoluteNumber looks for an absolute episode number of two to four digits.
+// Bracketed tags are ignored so that resolutions, CRC32 hashes and years are not
+// mistaken for the episode; numbers introduced by "E", "EP", "Episode" or " - "
+// take precedence over bare numbers.
+func findAbsoluteNumber(filename string) (EpisodeID, bool) {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	stripped := bracketRe.ReplaceAllStringFunc(name, func(tag string) string {
+		return strings.Repeat(" ", len(tag))
+	})
+
+	var ep EpisodeID
+	bestRank, bestEnd := 0, 0
+	for _, loc := range numberRe.FindAllStringSubmatchIndex(stripped, -1) {
+		digits := stripped[loc[2]:loc[3]]
+		if len(digits) < 2 || len(digits) > 4 {
+			continue
+		}
+		if !isNumberBoundary(stripped, loc[1]) {
+			continue
+		}
+
+		rank := numberRank(stripped[:loc[0]])
+		if rank == 0 {
+			continue
+		}
+		// A bare four-digit number in the 1900-2099 range is most likely a year
+		if value := atoi(digits); len(digits) == 4 && value >= 1900 && value < 2100 && rank < 3 {
+			continue
+		}
+		if rank > bestRank {
+			m := submatches(stripped, loc)
+			bestRank, bestEnd = rank, loc[1]
+			ep = EpisodeID{Episode: atoi(m[1]), Absolute: atoi(m[1]), Version: atoi(m[2])}
+		}
+	}
+	if bestRank > 0 {
+		ep.parseRange(stripped[bestEnd:], absoluteRangeRe)
+		return ep, true
+	}
+
+	// Fall back to a bracket holding only the episode number: "Show [05].mkv"
+	for _, tag := range bracketRe.FindAllString(name, -1) {
+		if m := bracketEpisodeRe.FindStringSubmatch(tag); m != nil {
+			n := atoi(m[1])
+			return EpisodeID{Episode: n, Absolute: n, Version: atoi(m[2])}, true
+		}
+	}
+	return EpisodeID{}, false
+}
+
+// numberRank scores the text preceding a number: 3 for an explicit episode marker
+// or a " - " separator, 2 for the start of the name, 1 for any other delimiter
+// and 0 when the number is glued to a word (x264, H.265, 5.1)
+func numberRank(before string) int {
+	lower := strings.ToLower(before)
+	trimmed := strings.TrimRight(lower, " ._-#")
+	for _, marker := range []string{"episode", "ep", "e"} {
+		if strings.HasSuffix(trimmed, marker) {
+			head := strings.TrimSuffix(trimmed, marker)
+			if head == "" || !isAlnum(head[len(head)-1]) {
+				return 3
+			}
+		}
+	}
+
+	if lower == "" {
+		return 2
+	}
+	last := lower[len(lower)-1]
+	switch {
+	case strings.HasSuffix(lower, "- ") || strings.HasSuffix(lower, "-"):
+		return 3
+	case last == ' ' || last == '_' || last == '#':
+		return 1
+	case last == '.':
+		// "H.264" / "x.265" style codec names
+		if len(lower) >= 2 && (lower[len(lower)-2] == 'h' || lower[len(lower)-2] == 'x') &&
+			(len(lower) == 2 || !isAlnum(lower[len(lower)-3])) {
+			return 0
+		}
+		return 1
+	}
+	return 0
+}
+
+// isNumberBoundary reports whether the number ending at end is followed by a delimiter
+func isNumberBoundary(s string, end int) bool {
+	if end == len(s) {
+		return true
+	}
+	switch s[end] {
+	case ' ', '.', '_', '-', '[', '(', ')', ']', ',':
+		return true
+	}
+	return false
+}
+
+func isAlnum(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// Key returns the identifier used to name extracted files (S01E05, SP01, 05, 01-02)
+func (e EpisodeID) Key() string {
+	key := e.FirstKey()
+	if e.EndEpisode > 0 {
+		if e.Season > 0 && !e.Special {
+			return fmt.Sprintf("%s-E%02d", key, e.EndEpisode)
+		}
+		return fmt.Sprintf("%s-%02d", key, e.EndEpisode)
+	}
+	return key
+}
+
+// FirstKey returns the identifier of the first episode, ignoring any range
+func (e EpisodeID) FirstKey() string {
+	switch {
+	case e.Special:
+		return fmt.Sprintf("SP%02d", e.Episode)
+	case e.Season > 0:
+		return fmt.Sprintf("S%02dE%02d", e.Season, e.Episode)
+	default:
+		return fmt.Sprintf("%02d", e.Episode)
+	}
+}
+
+// LegacyKey returns the bare two-digit episode number used by older releases
+// of mkvtea to name extracted files (e.g. 05_ita.ass)
+func (e EpisodeID) LegacyKey() string {
+	return fmt.Sprintf("%02d", e.Episode)
+}
 
 // GetEpisodeNumber extracts the episode number from a filename
 // Uses regex to match patterns like S01E02, EP02, 02, etc.
 func GetEpisodeNumber(filename string) string {
-	re := regexp.MustCompile(`(?i)(?:s\d{1,2}|e|ep|\s|\.|_|^)(\d{2})(?:\s|\.|_|v|$)`)
-	matches := re.FindStringSubmatch(filename)
-	if len(matches) > 1 {
-		return matches[1]
+	if ep, ok := ParseEpisode(filename); ok {
+		return ep.LegacyKey()
 	}
 	return "XX"
 }
+
+// episodeKeys returns the file name prefixes that identify the episode of a video,
+// in order of preference: the full key (including any range), the key of the first
+// episode of a range, then the legacy two-digit fallback
+func episodeKeys(filename string, cfg config.Config) []string {
+	rel, ok := ParseRelease(filename, cachedEpisodePattern(cfg.EpisodePattern))
+	if !ok {
+		return []string{"XX"}
+	}
+	ep := rel.Episode
+	var keys []string
+	for _, key := range []string{ep.Key(), ep.FirstKey(), ep.LegacyKey()} {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// hasEpisodePrefix reports whether name starts with key as a whole token,
+// so that "05" matches "05_ita.ass" but neither "050_ita.ass" nor "05-06_ita.ass"
+func hasEpisodePrefix(name, key string) bool {
+	if len(name) < len(key) || !strings.EqualFold(name[:len(key)], key) {
+		return false
+	}
+	rest := strings.ToLower(name[len(key):])
+	rest = strings.TrimPrefix(rest, "-")
+	rest = strings.TrimPrefix(rest, "e")
+	if rest != "" && len(rest) < len(name)-len(key) && rest[0] >= '0' && rest[0] <= '9' {
+		return false // continues into an episode range
+	}
+	next := name[len(key):]
+	return next == "" || next[0] < '0' || next[0] > '9'
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -1,6 +1,10 @@
 package mkv
 
-import "testing"
+import (
+	"mkvtea/internal/config"
+	"strings"
+	"testing"
+)
 
 func TestGetEpisodeNumber(t *testing.T) {
 	tests := []struct {
@@ -69,3 +73,163 @@ func TestGetEpisodeNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestParseEpisode(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected EpisodeID
+		key      string
+	}{
+		{"Anime Title S01E05.mkv", EpisodeID{Season: 1, Episode: 5}, "S01E05"},
+		{"Anime Title S02E05.mkv", EpisodeID{Season: 2, Episode: 5}, "S02E05"},
+		{"show.s1e12v2.mkv", EpisodeID{Season: 1, Episode: 12, Version: 2}, "S01E12"},
+		{"[Group] Show - 07v2 [1080p].mkv", EpisodeID{Episode: 7, Absolute: 7, Version: 2}, "07"},
+		{"Show OVA 02.mkv", EpisodeID{Episode: 2, Special: true}, "SP02"},
+		{"Show - SP01.mkv", EpisodeID{Episode: 1, Special: true}, "SP01"},
+		{"episode_01.mkv", EpisodeID{Episode: 1, Absolute: 1}, "01"},
+		{"Show Season 2 - 05.mkv", EpisodeID{Season: 2, Episode: 5}, "S02E05"},
+		{"[Group] Show Season 12 - 03v2 [1080p].mkv", EpisodeID{Season: 12, Episode: 3, Version: 2}, "S12E03"},
+		{"Show.Season.1.07.mkv", EpisodeID{Season: 1, Episode: 7}, "S01E07"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			got, ok := ParseEpisode(tt.filename)
+			if !ok {
+				t.Fatalf("ParseEpisode(%q) found no episode", tt.filename)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseEpisode(%q) = %+v; want %+v", tt.filename, got, tt.expected)
+			}
+			if got.Key() != tt.key {
+				t.Errorf("ParseEpisode(%q).Key() = %q; want %q", tt.filename, got.Key(), tt.key)
+			}
+		})
+	}
+
+	if _, ok := ParseEpisode("opening.mkv"); ok {
+		t.Errorf("ParseEpisode(%q) should not find an episode", "opening.mkv")
+	}
+}
+
+func TestEpisodeKeys(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected []string
+	}{
+		{"Show S02E05.mkv", []string{"S02E05", "05"}},
+		{"Show - 01-02.mkv", []string{"01-02", "01"}},
+		{"Show S01E01E02.mkv", []string{"S01E01-E02", "S01E01", "01"}},
+		{"Show - 05.mkv", []string{"05"}},
+		{"opening.mkv", []string{"XX"}},
+	}
+
+	for _, tt := range tests {
+		got := episodeKeys(tt.filename, config.Config{})
+		if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("episodeKeys(%q) = %v; want %v", tt.filename, got, tt.expected)
+		}
+	}
+}
+
+func TestHasEpisodePrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		expected bool
+	}{
+		{"05_ita.ass", "05", true},
+		{"050_ita.ass", "05", false},
+		{"S01E05_ita.ass", "S01E05", true},
+		{"s01e05_ita.ass", "S01E05", true},
+		{"S01E05_ita.ass", "05", false},
+		{"05", "05", true},
+		{"05v2_ita.ass", "05", true},
+		{"05-06_ita.ass", "05", false},
+		{"05-06_ita.ass", "05-06", true},
+		{"S01E05E06_ita.ass", "S01E05", false},
+	}
+
+	for _, tt := range tests {
+		got := hasEpisodePrefix(tt.name, tt.key)
+		if got != tt.expected {
+			t.Errorf("hasEpisodePrefix(%q, %q) = %v; want %v", tt.name, tt.key, got, tt.expected)
+		}
+	}
+}
+
+func TestParseEpisodeAbsoluteNumbers(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected int
+	}{
+		{"[Group] One Piece - 1089 [1080p][ABCD1234].mkv", 1089},
+		{"Detective Conan - 1100 (2023) [1080p].mkv", 1100},
+		{"[Group] Show - 101 [1080p].mkv", 101},
+		{"Show 2023 - 05 [x264].mkv", 5},
+		{"Show.Ep.250.1080p.H.264.mkv", 250},
+		{"Show [1920x1080] - 12.mkv", 12},
+		{"[Group] Show [07v2].mkv", 7},
+		{"Show.Episode_123.mkv", 123},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			got, ok := ParseEpisode(tt.filename)
+			if !ok {
+				t.Fatalf("ParseEpisode(%q) found no episode", tt.filename)
+			}
+			if got.Absolute != tt.expected {
+				t.Errorf("ParseEpisode(%q).Absolute = %d; want %d", tt.filename, got.Absolute, tt.expected)
+			}
+		})
+	}
+
+	for _, filename := range []string{"Movie (2023) [1080p].mkv", "Show [ABCD1234].mkv", "Show.1080p.x265.mkv"} {
+		if ep, ok := ParseEpisode(filename); ok {
+			t.Errorf("ParseEpisode(%q) = %+v; want no episode", filename, ep)
+		}
+	}
+
+	// Episode 1 and episode 101 must not collide
+	one, _ := ParseEpisode("Show - 01.mkv")
+	hundredOne, _ := ParseEpisode("Show - 101.mkv")
+	if one.Key() == hundredOne.Key() || hasEpisodePrefix(hundredOne.Key()+"_ita.ass", one.Key()) {
+		t.Errorf("keys %q and %q collide", one.Key(), hundredOne.Key())
+	}
+}
+
+func TestParseEpisodeRanges(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected EpisodeID
+		key      string
+	}{
+		{"Show - 01-02.mkv", EpisodeID{Episode: 1, Absolute: 1, EndEpisode: 2}, "01-02"},
+		{"Show S01E01E02.mkv", EpisodeID{Season: 1, Episode: 1, EndEpisode: 2}, "S01E01-E02"},
+		{"Show S01E01-E02 [1080p].mkv", EpisodeID{Season: 1, Episode: 1, EndEpisode: 2}, "S01E01-E02"},
+		{"Show.S02E03-04.mkv", EpisodeID{Season: 2, Episode: 3, EndEpisode: 4}, "S02E03-E04"},
+		{"Show - 1000-1001v2.mkv", EpisodeID{Episode: 1000, Absolute: 1000, EndEpisode: 1001, Version: 2}, "1000-1001"},
+		{"Show S01E02-1080p.mkv", EpisodeID{Season: 1, Episode: 2}, "S01E02"},
+		{"Show - 05 - 06.mkv", EpisodeID{Episode: 5, Absolute: 5, EndEpisode: 6}, "05-06"},
+		{"[Group] Show - 11 - 12v2 [720p].mkv", EpisodeID{Episode: 11, Absolute: 11, EndEpisode: 12, Version: 2}, "11-12"},
+		{"Show Season 2 - 05 - 06.mkv", EpisodeID{Season: 2, Episode: 5, EndEpisode: 6}, "S02E05-E06"},
+		{"Show - 05 - 2023.mkv", EpisodeID{Episode: 5, Absolute: 5}, "05"},
+		{"Show - 05 - 1080p.mkv", EpisodeID{Episode: 5, Absolute: 5}, "05"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			got, ok := ParseEpisode(tt.filename)
+			if !ok {
+				t.Fatalf("ParseEpisode(%q) found no episode", tt.filename)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseEpisode(%q) = %+v; want %+v", tt.filename, got, tt.expected)
+			}
+			if got.Key() != tt.key {
+				t.Errorf("ParseEpisode(%q).Key() = %q; want %q", tt.filename, got.Key(), tt.key)
+			}
+		})
+	}
+}
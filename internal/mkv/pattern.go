@@ -0,0 +1,113 @@
+package mkv
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// patternGroups lists the named capture groups accepted in a custom episode pattern
+var patternGroups = []string{"season", "episode", "title", "group"}
+
+// episodeGroupRe parses the text captured by the "episode" group: 05, 05v2, 01-02, 01-E02
+var episodeGroupRe = regexp.MustCompile(`(?i)^e?p?(\d+)(?:v(\d))?(?:-e?(\d+)(?:v(\d))?)?$`)
+
+// EpisodePattern is a user-defined file name regex with named capture groups
+type EpisodePattern struct {
+	re *regexp.Regexp
+}
+
+// CompileEpisodePattern validates and compiles a custom episode pattern.
+// The pattern must define an "episode" group and may define "season", "title" and "group".
+func CompileEpisodePattern(expr string) (*EpisodePattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %v", err)
+	}
+
+	hasEpisode := false
+	for _, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(patternGroups, name) {
+			return nil, fmt.Errorf("unknown capture group %q (allowed: %s)", name, strings.Join(patternGroups, ", "))
+		}
+		if name == "episode" {
+			hasEpisode = true
+		}
+	}
+	if !hasEpisode {
+		return nil, fmt.Errorf("pattern must define an (?P<episode>...) capture group")
+	}
+
+	return &EpisodePattern{re: re}, nil
+}
+
+// Parse resolves a file name with the pattern. The boolean result is false when the
+// pattern does not match or the captured episode is not a number.
+func (p *EpisodePattern) Parse(filename string) (Release, bool) {
+	m := p.re.FindStringSubmatch(filename)
+	if m == nil {
+		return Release{}, false
+	}
+
+	var rel Release
+	var episode, season string
+	for i, name := range p.re.SubexpNames() {
+		switch name {
+		case "episode":
+			episode = m[i]
+		case "season":
+			season = m[i]
+		case "title":
+			rel.Title = strings.TrimSpace(m[i])
+		case "group":
+			rel.Group = strings.TrimSpace(m[i])
+		}
+	}
+
+	em := episodeGroupRe.FindStringSubmatch(strings.TrimSpace(episode))
+	if em == nil {
+		return Release{}, false
+	}
+	ep := EpisodeID{Episode: atoi(em[1]), Version: max(atoi(em[2]), atoi(em[4]))}
+	if end := atoi(em[3]); end > ep.Episode {
+		ep.EndEpisode = end
+	}
+	if s := atoi(strings.TrimLeft(strings.ToLower(strings.TrimSpace(season)), "s")); s > 0 {
+		ep.Season = s
+	} else {
+		ep.Absolute = ep.Episode
+	}
+	rel.Episode = ep
+	return rel, true
+}
+
+var (
+	patternCacheMu sync.Mutex
+	patternCache   = map[string]*EpisodePattern{}
+)
+
+// cachedEpisodePattern returns the compiled form of expr, or nil when expr is empty
+// or invalid. Patterns are validated at startup, so errors are not reported here.
+func cachedEpisodePattern(expr string) *EpisodePattern {
+	if expr == "" {
+		return nil
+	}
+
+	patternCacheMu.Lock()
+	defer patternCacheMu.Unlock()
+
+	if p, ok := patternCache[expr]; ok {
+		return p
+	}
+	p, err := CompileEpisodePattern(expr)
+	if err != nil {
+		p = nil
+	}
+	patternCache[expr] = p
+	return p
+}
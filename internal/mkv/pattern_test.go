@@ -0,0 +1,68 @@
+package mkv
+
+import "testing"
+
+func TestCompileEpisodePattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		valid   bool
+	}{
+		{"episode only", `Ep(?P<episode>\d+)`, true},
+		{"all groups", `^\[(?P<group>[^\]]+)\] (?P<title>.+?) S(?P<season>\d+)E(?P<episode>\d+)`, true},
+		{"missing episode group", `(?P<title>.+) (\d+)`, false},
+		{"unknown group", `(?P<episode>\d+) (?P<res>\d+p)`, false},
+		{"invalid regex", `(?P<episode>\d+`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileEpisodePattern(tt.pattern)
+			if (err == nil) != tt.valid {
+				t.Errorf("CompileEpisodePattern(%q) error = %v; want valid=%v", tt.pattern, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestEpisodePatternParse(t *testing.T) {
+	p, err := CompileEpisodePattern(`^(?P<group>\w+)_(?P<title>.+?)_s(?P<season>\d+)_ep(?P<episode>[\dv-]+)`)
+	if err != nil {
+		t.Fatalf("CompileEpisodePattern failed: %v", err)
+	}
+
+	rel, ok := p.Parse("Grp_My Show_s2_ep07v2.mkv")
+	if !ok {
+		t.Fatalf("Parse found no match")
+	}
+	expected := EpisodeID{Season: 2, Episode: 7, Version: 2}
+	if rel.Episode != expected {
+		t.Errorf("Episode = %+v; want %+v", rel.Episode, expected)
+	}
+	if rel.Title != "My Show" || rel.Group != "Grp" {
+		t.Errorf("Title/Group = %q/%q; want %q/%q", rel.Title, rel.Group, "My Show", "Grp")
+	}
+
+	rel, ok = p.Parse("Grp_My Show_s1_ep01-02.mkv")
+	if !ok || rel.Episode.Key() != "S01E01-E02" {
+		t.Errorf("Parse range = %+v, %v; want key S01E01-E02", rel.Episode, ok)
+	}
+
+	if _, ok := p.Parse("Other Show - 05.mkv"); ok {
+		t.Errorf("Parse should not match an unrelated name")
+	}
+}
+
+func TestParseReleaseFallback(t *testing.T) {
+	p, _ := CompileEpisodePattern(`Chapter (?P<episode>\d+)`)
+
+	rel, ok := ParseRelease("Show Chapter 3.mkv", p)
+	if !ok || rel.Episode.Key() != "03" || rel.ParsedBy != "pattern" {
+		t.Errorf("ParseRelease with pattern = %+v, %v", rel, ok)
+	}
+
+	rel, ok = ParseRelease("Show - 12.mkv", p)
+	if !ok || rel.Episode.Key() != "12" || rel.ParsedBy != "built-in" {
+		t.Errorf("ParseRelease fallback = %+v, %v", rel, ok)
+	}
+}
@@ -0,0 +1,138 @@
+package mkv
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Release describes what a video file name resolves to, e.g.
+// "[Group] Title - 07v2 [1080p][ABCD1234].mkv"
+type Release struct {
+	Group      string    // Release group, e.g. "SubsPlease"
+	Title      string    // Series title
+	Episode    EpisodeID // Parsed episode identity (including the v2/v3 version)
+	Resolution string    // Normalized resolution, e.g. "1080p"
+	Source     string    // Source medium, e.g. "BD", "WEB", "DVD", "TV"
+	CRC32      string    // Upper-case CRC32 hash embedded in brackets
+	ParsedBy   string    // "pattern" when resolved by a custom pattern, "built-in" otherwise
+}
+
+var (
+	// Leading [Group] tag
+	groupRe = regexp.MustCompile(`^\s*\[([^\]]+)\]`)
+	// [ABCD1234] or (ABCD1234)
+	crcRe = regexp.MustCompile(`[\[(]([0-9A-Fa-f]{8})[\])]`)
+	// A whole bracketed tag holding a CRC32 candidate
+	crcTagRe = regexp.MustCompile(`^[\[(]([0-9A-Fa-f]{8})[\])]$`)
+	// 1080p, 720p, 2160p, 480i, 4K, 1920x1080
+	resolutionRe = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:(\d{3,4})[pi]|\d{3,4}x(\d{3,4})|(4k))(?:[^a-z0-9]|$)`)
+	// BD, BDRip, Blu-ray, WEB, WEB-DL, WEBRip, DVD, DVDRip, TV, HDTV
+	sourceRe = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(bd(?:rip|remux)?|blu-?ray|web(?:-?dl|-?rip)?|dvd(?:rip)?|hdtv|tv)(?:[^a-z0-9]|$)`)
+	// Title up to the episode marker: " - 07", " S01E07", " Ep07", or the first bracket
+	titleRe = regexp.MustCompile(`(?i)^(.+?)(?:\s+-\s+\d|[ ._-]+s\d{1,2}[ ._-]?e\d|[ ._-]+(?:ep?|episode)[ ._]?\d|[ ._-]+(?:ova|oad|sp|special)[ ._-]?\d|[ ._]+\d{2,4}(?:v\d)?(?:[ ._]|$)|\s*[\[(]|$)`)
+)
+
+// ParseRelease resolves a file name, trying the custom pattern first (when not nil)
+// and falling back to the built-in heuristics. Fansub tags (group, resolution, source,
+// CRC32) are filled in from the name in both cases.
+func ParseRelease(filename string, pattern *EpisodePattern) (Release, bool) {
+	var rel Release
+	ok := false
+	if pattern != nil {
+		rel, ok = pattern.Parse(filename)
+		rel.ParsedBy = "pattern"
+	}
+	if !ok {
+		ep, found := ParseEpisode(filename)
+		if !found {
+			return Release{}, false
+		}
+		rel = Release{Episode: ep, ParsedBy: "built-in"}
+	}
+
+	tags := parseReleaseTags(filename)
+	if rel.Group == "" {
+		rel.Group = tags.Group
+	}
+	if rel.Title == "" {
+		rel.Title = tags.Title
+	}
+	rel.Resolution = tags.Resolution
+	rel.Source = tags.Source
+	rel.CRC32 = tags.CRC32
+	return rel, true
+}
+
+// parseReleaseTags extracts the fansub naming fields that don't depend on the episode number
+func parseReleaseTags(filename string) Release {
+	var rel Release
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+
+	if m := groupRe.FindStringSubmatch(name); m != nil {
+		rel.Group = strings.TrimSpace(m[1])
+		name = name[len(m[0]):]
+	}
+
+	rel.CRC32 = crc32Tag(name)
+
+	if m := resolutionRe.FindStringSubmatch(name); m != nil {
+		switch {
+		case m[1] != "":
+			rel.Resolution = m[1] + "p"
+		case m[2] != "":
+			rel.Resolution = m[2] + "p"
+		default:
+			rel.Resolution = "2160p"
+		}
+	}
+
+	if m := sourceRe.FindStringSubmatch(name); m != nil {
+		rel.Source = normalizeSource(m[1])
+	}
+
+	if m := titleRe.FindStringSubmatch(strings.TrimSpace(name)); m != nil {
+		title := m[1]
+		// Scene-style names use dots or underscores instead of spaces
+		if !strings.Contains(title, " ") {
+			title = strings.NewReplacer(".", " ", "_", " ").Replace(title)
+		}
+		rel.Title = strings.TrimSpace(strings.TrimRight(title, " -_."))
+	}
+	return rel
+}
+
+// crc32Tag returns the CRC32 of a release name, which fansub groups put in the last
+// bracketed tag. Dates such as (20231015) are not hashes.
+func crc32Tag(name string) string {
+	tags := bracketRe.FindAllString(name, -1)
+	if len(tags) == 0 {
+		return ""
+	}
+	m := crcTagRe.FindStringSubmatch(tags[len(tags)-1])
+	if m == nil || isDate(m[1]) {
+		return ""
+	}
+	return strings.ToUpper(m[1])
+}
+
+// isDate reports whether an eight-digit tag reads as a plausible YYYYMMDD date
+func isDate(s string) bool {
+	date, err := time.Parse("20060102", s)
+	return err == nil && date.Year() >= 1900 && date.Year() < 2100
+}
+
+func normalizeSource(s string) string {
+	s = strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(s, "bd") || strings.HasPrefix(s, "blu"):
+		return "BD"
+	case strings.HasPrefix(s, "web"):
+		return "WEB"
+	case strings.HasPrefix(s, "dvd"):
+		return "DVD"
+	default:
+		return "TV"
+	}
+}
@@ -0,0 +1,70 @@
+package mkv
+
+import "testing"
+
+func TestParseRelease(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected Release
+	}{
+		{
+			filename: "[SubsPlease] Sousou no Frieren - 07v2 [1080p][ABCD1234].mkv",
+			expected: Release{Group: "SubsPlease", Title: "Sousou no Frieren", Episode: EpisodeID{Episode: 7, Absolute: 7, Version: 2},
+				Resolution: "1080p", CRC32: "ABCD1234", ParsedBy: "built-in"},
+		},
+		{
+			filename: "Show.Name.S01E05.720p.WEB-DL.x264.mkv",
+			expected: Release{Title: "Show Name", Episode: EpisodeID{Season: 1, Episode: 5},
+				Resolution: "720p", Source: "WEB", ParsedBy: "built-in"},
+		},
+		{
+			filename: "[Grp] Title [BD][10bit][e0512345] - 12.mkv",
+			expected: Release{Group: "Grp", Title: "Title", Episode: EpisodeID{Episode: 12, Absolute: 12},
+				Source: "BD", CRC32: "E0512345", ParsedBy: "built-in"},
+		},
+		{
+			filename: "[Judas] One Piece - 1089 (1920x1080 BDRip).mkv",
+			expected: Release{Group: "Judas", Title: "One Piece", Episode: EpisodeID{Episode: 1089, Absolute: 1089},
+				Resolution: "1080p", Source: "BD", ParsedBy: "built-in"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			got, ok := ParseRelease(tt.filename, nil)
+			if !ok {
+				t.Fatalf("ParseRelease(%q) found no episode", tt.filename)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseRelease(%q) =\n  %+v\nwant\n  %+v", tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCRC32Tag(t *testing.T) {
+	tests := map[string]string{
+		"[Grp] Show - 05 [1080p][ABCD1234]":            "ABCD1234",
+		"[Grp] Show - 05 [1080p][abcd1234]":            "ABCD1234",
+		"[Grp] Show - 05 (20231015)":                   "",
+		"[Grp] Show (20231015) - 05 [1080p]":           "",
+		"[Grp] Show (20231015) - 05 [1080p][12345678]": "12345678",
+		"[Grp] Show - 05 [ABCD1234][1080p]":            "",
+		"[Grp] Show - 05 [20231399]":                   "20231399",
+		"[Grp] Show - 05 [30001015]":                   "30001015",
+	}
+	for name, want := range tests {
+		if got := crc32Tag(name); got != want {
+			t.Errorf("crc32Tag(%q) = %q; want %q", name, got, want)
+		}
+	}
+}
+
+func TestParseEpisodeIgnoresTags(t *testing.T) {
+	// Bracketed tags must never be read as the episode number
+	for _, filename := range []string{"[Grp] Show [10bit].mkv", "[Grp] Show [SE012345].mkv", "[Grp] Show [1080p][ABCD1234].mkv"} {
+		if ep, ok := ParseEpisode(filename); ok {
+			t.Errorf("ParseEpisode(%q) = %+v; want no episode", filename, ep)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package mkv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// sidecarExt is appended to an extracted track's file name to name its sidecar
+const sidecarExt = ".json"
+
+// TrackSidecar records the properties of an extracted track that its file format
+// can't hold, so that merge can restore them
+type TrackSidecar struct {
+	TrackID         int    `json:"track_id"` // Position of the track in the source file
+	Type            string `json:"type"`
+	Name            string `json:"name,omitempty"`
+	Language        string `json:"language"`
+	LanguageIETF    string `json:"language_ietf,omitempty"`
+	Default         bool   `json:"default"`
+	Forced          bool   `json:"forced"`
+	HearingImpaired bool   `json:"hearing_impaired"`
+	CodecDelay      int64  `json:"codec_delay,omitempty"` // Nanoseconds
+	CodecID         string `json:"codec_id,omitempty"`
+}
+
+func newTrackSidecar(t Track) TrackSidecar {
+	return TrackSidecar{
+		TrackID:         t.ID,
+		Type:            t.Type,
+		Name:            t.Props.TrackName,
+		Language:        t.Props.Lang,
+		LanguageIETF:    t.Props.LangIETF,
+		Default:         t.Props.Default,
+		Forced:          t.Props.Forced,
+		HearingImpaired: t.Props.HearingImpaired,
+		CodecDelay:      t.Props.CodecDelay,
+		CodecID:         t.Props.CodecID,
+	}
+}
+
+// writeSidecar stores the sidecar of the track extracted to output in the lang folder.
+// Undefined tracks record the language they were filed under (--undefined), so merge
+// tags them like the rest of the folder.
+func writeSidecar(output string, t Track, lang string) error {
+	sc := newTrackSidecar(t)
+	if (t.Props.Lang == "" || t.Props.Lang == undefinedLang) && lang != "" && lang != undefinedLang {
+		sc.Language, sc.LanguageIETF = lang, ""
+	}
+	data, err := json.MarshalIndent(sc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output+sidecarExt, append(data, '\n'), 0644)
+}
+
+// language returns the language merge tags the track with: the IETF tag when known,
+// then the ISO 639-2 code, then fallback (--lang) for files without a sidecar
+func (sc TrackSidecar) language(fallback string) string {
+	switch {
+	case sc.LanguageIETF != "":
+		return sc.LanguageIETF
+	case sc.Language != "":
+		return sc.Language
+	}
+	return fallback
+}
+
+// syncDelay returns the mkvmerge --sync delay (ms) restoring the codec delay of the track.
+// Opus files carry their pre-skip themselves, so mkvmerge derives their delay again.
+func (sc TrackSidecar) syncDelay() int64 {
+	if sc.CodecDelay == 0 || sc.CodecID == "A_OPUS" {
+		return 0
+	}
+	// Players subtract the codec delay from every timestamp; rounded to milliseconds
+	return -((sc.CodecDelay + 500000) / 1000000)
+}
+
+// loadSidecar reads the sidecar of an external track file, if there is one
+func loadSidecar(file string) (TrackSidecar, bool) {
+	var sc TrackSidecar
+	data, err := os.ReadFile(file + sidecarExt)
+	if err != nil {
+		return sc, false
+	}
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return sc, false
+	}
+	return sc, true
+}
+
+// trackOrderArg builds the mkvmerge --track-order value that puts external tracks
+// back at their original position among the tracks kept from the video (file 0).
+// externals[i] is the original track ID of input file i+1, or -1 when unknown.
+func trackOrderArg(kept []Track, externals []int) string {
+	type entry struct {
+		file, track, position int
+	}
+	var entries []entry
+	for _, t := range kept {
+		entries = append(entries, entry{file: 0, track: t.ID, position: t.ID})
+	}
+	// Tracks without a sidecar keep their place after every known position
+	last := 0
+	for _, e := range entries {
+		last = max(last, e.position+1)
+	}
+	for _, id := range externals {
+		last = max(last, id+1)
+	}
+	for i, id := range externals {
+		position := id
+		if position < 0 {
+			position = last + i
+		}
+		entries = append(entries, entry{file: i + 1, track: 0, position: position})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].position != entries[j].position {
+			return entries[i].position < entries[j].position
+		}
+		return entries[i].file < entries[j].file
+	})
+
+	order := ""
+	for i, e := range entries {
+		if i > 0 {
+			order += ","
+		}
+		order += fmt.Sprintf("%d:%d", e.file, e.track)
+	}
+	return order
+}
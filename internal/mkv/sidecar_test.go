@@ -0,0 +1,55 @@
+package mkv
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSidecarRoundTrip(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "05_ita_3.ass")
+	track := Track{ID: 3, Type: "subtitles"}
+	track.Props.TrackName = "Signs & Songs"
+	track.Props.Lang = "ita"
+	track.Props.Forced = true
+	track.Props.HearingImpaired = true
+
+	if err := writeSidecar(output, track, "ita"); err != nil {
+		t.Fatalf("writeSidecar failed: %v", err)
+	}
+	sc, ok := loadSidecar(output)
+	if !ok {
+		t.Fatalf("loadSidecar found no sidecar")
+	}
+	if sc != newTrackSidecar(track) {
+		t.Errorf("loadSidecar = %+v; want %+v", sc, newTrackSidecar(track))
+	}
+
+	got := strings.Join(externalTrackArgs(sc, "ita"), " ")
+	want := "--language 0:ita --track-name 0:Signs & Songs --default-track 0:no --forced-display-flag 0:yes --hearing-impaired-flag 0:yes"
+	if got != want {
+		t.Errorf("externalTrackArgs = %q; want %q", got, want)
+	}
+
+	if _, ok := loadSidecar(filepath.Join(t.TempDir(), "missing.ass")); ok {
+		t.Errorf("loadSidecar found a sidecar for a file without one")
+	}
+}
+
+func TestTrackOrderArg(t *testing.T) {
+	kept := []Track{{ID: 0}, {ID: 1}, {ID: 4}}
+	tests := []struct {
+		externals []int
+		expected  string
+	}{
+		// Subtitles originally at positions 2 and 3 go back between the audio tracks
+		{[]int{2, 3}, "0:0,0:1,1:0,2:0,0:4"},
+		// Files without a sidecar are appended
+		{[]int{-1, 2}, "0:0,0:1,2:0,0:4,1:0"},
+	}
+	for _, tt := range tests {
+		if got := trackOrderArg(kept, tt.externals); got != tt.expected {
+			t.Errorf("trackOrderArg(%v) = %q; want %q", tt.externals, got, tt.expected)
+		}
+	}
+}
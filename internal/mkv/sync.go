@@ -0,0 +1,78 @@
+package mkv
+
+import (
+	"fmt"
+	"math/big"
+	"mkvtea/internal/config"
+	"strings"
+)
+
+// ValidateSubFPS checks a --sub-fps value: "" or from:to framerates (23.976:25, 24000/1001:25)
+func ValidateSubFPS(fps string) error {
+	_, err := parseFPSRatio(fps)
+	return err
+}
+
+// parseFPSRatio returns the factor applied to the timestamps of subtitles timed for the
+// "from" framerate so that they fit a video at the "to" framerate (nil when fps is "")
+func parseFPSRatio(fps string) (*big.Rat, error) {
+	if fps == "" {
+		return nil, nil
+	}
+	from, to, ok := strings.Cut(fps, ":")
+	if !ok {
+		return nil, fmt.Errorf("%q is not in the from:to format (e.g. 25:23.976)", fps)
+	}
+	fromRate, err := parseFramerate(from)
+	if err != nil {
+		return nil, err
+	}
+	toRate, err := parseFramerate(to)
+	if err != nil {
+		return nil, err
+	}
+	// A frame shown at n/from seconds is shown at n/to seconds in the target video
+	return new(big.Rat).Quo(fromRate, toRate), nil
+}
+
+func parseFramerate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid framerate %q", s)
+	}
+	return rate, nil
+}
+
+// subtitleSync returns the mkvmerge --sync value (without track ID) for an external
+// subtitle of an entry, "" when no shift is needed. Per-episode values of the pairing
+// manifest take precedence over --sub-delay and --sub-fps. offset (ms) is added to the
+// delay, so that the codec delay restored from a sidecar and the user's shift end up in
+// the single --sync option mkvmerge keeps per input.
+func subtitleSync(assets PairingEntry, cfg config.Config, offset int64) (string, error) {
+	delay, fps := cfg.SubDelay, cfg.SubFPS
+	if assets.SubDelay != nil {
+		delay = *assets.SubDelay
+	}
+	if assets.SubFPS != "" {
+		fps = assets.SubFPS
+	}
+
+	ratio, err := parseFPSRatio(fps)
+	if err != nil {
+		return "", err
+	}
+	if ratio != nil && ratio.Cmp(big.NewRat(1, 1)) == 0 {
+		ratio = nil
+	}
+	delay += offset
+	if delay == 0 && ratio == nil {
+		return "", nil
+	}
+
+	sync := fmt.Sprintf("%d", delay)
+	if ratio != nil {
+		// Integers keep NTSC rates (24000/1001) exact
+		sync += fmt.Sprintf(",%s/%s", ratio.Num(), ratio.Denom())
+	}
+	return sync, nil
+}
@@ -0,0 +1,56 @@
+package mkv
+
+import (
+	"mkvtea/internal/config"
+	"testing"
+)
+
+func TestSubtitleSync(t *testing.T) {
+	delay := func(ms int64) *int64 { return &ms }
+
+	tests := []struct {
+		name   string
+		assets PairingEntry
+		cfg    config.Config
+		offset int64
+		want   string
+	}{
+		{"none", PairingEntry{}, config.Config{}, 0, ""},
+		{"delay", PairingEntry{}, config.Config{SubDelay: -1500}, 0, "-1500"},
+		{"pal to film", PairingEntry{}, config.Config{SubFPS: "25:23.976"}, 0, "0,3125/2997"},
+		{"ntsc fraction", PairingEntry{}, config.Config{SubDelay: 200, SubFPS: "24000/1001:24"}, 0, "200,1000/1001"},
+		{"same framerate", PairingEntry{}, config.Config{SubFPS: "25:25"}, 0, ""},
+		{"episode delay", PairingEntry{SubDelay: delay(750)}, config.Config{SubDelay: 100}, 0, "750"},
+		{"episode disables delay", PairingEntry{SubDelay: delay(0)}, config.Config{SubDelay: 100}, 0, ""},
+		{"episode framerate", PairingEntry{SubFPS: "23.976:25"}, config.Config{SubDelay: 100}, 0, "100,2997/3125"},
+		{"codec delay only", PairingEntry{}, config.Config{}, -7, "-7"},
+		{"codec delay and user delay", PairingEntry{}, config.Config{SubDelay: 500}, -7, "493"},
+		{"codec delay and framerate", PairingEntry{}, config.Config{SubFPS: "25:23.976"}, -7, "-7,3125/2997"},
+		{"delays cancel out", PairingEntry{}, config.Config{SubDelay: 7}, -7, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := subtitleSync(tt.assets, tt.cfg, tt.offset)
+			if err != nil {
+				t.Fatalf("subtitleSync failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("subtitleSync = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSubFPS(t *testing.T) {
+	for _, fps := range []string{"", "25:23.976", "24000/1001:25"} {
+		if err := ValidateSubFPS(fps); err != nil {
+			t.Errorf("ValidateSubFPS(%q) failed: %v", fps, err)
+		}
+	}
+	for _, fps := range []string{"25", "25:", "0:25", "abc:25"} {
+		if err := ValidateSubFPS(fps); err == nil {
+			t.Errorf("ValidateSubFPS(%q) should fail", fps)
+		}
+	}
+}
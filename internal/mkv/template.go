@@ -0,0 +1,247 @@
+package mkv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// NameFields holds the values substituted into an output name template
+type NameFields struct {
+	Show      string // {show}: series title parsed from the video name
+	Season    string // {season}: two-digit season, empty when the name has none
+	Episode   string // {episode}: episode number, range or special (05, 01-02, SP01)
+	Lang      string // {lang}: language code of the track
+	TrackID   string // {track_id}: mkvmerge track ID
+	TrackName string // {track_name}: track name stored in the file
+	Forced    string // {forced}: "forced" for forced/sign tracks, empty otherwise
+	CodecExt  string // {codec_ext}: file extension for the codec, without the dot
+}
+
+// templatePlaceholders maps each placeholder to the regex used to recognise it in file names
+var templatePlaceholders = map[string]string{
+	"show":       `.*?`,
+	"season":     `\d*`,
+	"episode":    `(?i:sp)?\d+(?:v\d+)?(?:-(?i:e)?\d+(?:v\d+)?)?`,
+	"lang":       `[A-Za-z]{2,3}(?:-[A-Za-z0-9]+)*`,
+	"track_id":   `\d*`,
+	"track_name": `.*?`,
+	"forced":     `(?:forced)?`,
+	"codec_ext":  `[A-Za-z0-9]+`,
+}
+
+var (
+	placeholderRe = regexp.MustCompile(`\{([a-z_]+)\}`)
+	separatorRe   = regexp.MustCompile(`^[._ -]+$`)
+	unsafeCharsRe = regexp.MustCompile(`[/\\:*?"<>|]`)
+	// Version suffixes inside an episode field: 05v2, 01v2-02v2
+	episodeVersionRe = regexp.MustCompile(`(?i)v(\d+)`)
+)
+
+// templateToken is either a literal text run or a placeholder name
+type templateToken struct {
+	literal     string
+	placeholder string
+}
+
+// ValidateNameTemplate checks that a template only uses known placeholders,
+// identifies the episode and stays within a single directory
+func ValidateNameTemplate(tmpl string) error {
+	if strings.ContainsAny(tmpl, `/\`) {
+		return fmt.Errorf("template must not contain path separators")
+	}
+	hasEpisode := false
+	for _, m := range placeholderRe.FindAllStringSubmatch(tmpl, -1) {
+		if _, ok := templatePlaceholders[m[1]]; !ok {
+			return fmt.Errorf("unknown placeholder {%s}", m[1])
+		}
+		if m[1] == "episode" {
+			hasEpisode = true
+		}
+	}
+	if !hasEpisode {
+		return fmt.Errorf("template must contain {episode}")
+	}
+	return nil
+}
+
+// RenderName fills a template with the given fields. Empty placeholders drop the separator
+// in front of them, and ".{codec_ext}" is appended when the template doesn't place it.
+func RenderName(tmpl string, f NameFields) string {
+	values := map[string]string{
+		"show": f.Show, "season": f.Season, "episode": f.Episode, "lang": f.Lang,
+		"track_id": f.TrackID, "track_name": f.TrackName, "forced": f.Forced, "codec_ext": f.CodecExt,
+	}
+
+	tokens := tokenizeTemplate(tmpl)
+	var sb strings.Builder
+	for i, tok := range tokens {
+		if tok.placeholder == "" {
+			// Skip a pure separator run when the next placeholder renders empty
+			if separatorRe.MatchString(tok.literal) && i+1 < len(tokens) &&
+				tokens[i+1].placeholder != "" && values[tokens[i+1].placeholder] == "" {
+				continue
+			}
+			sb.WriteString(tok.literal)
+			continue
+		}
+		sb.WriteString(unsafeCharsRe.ReplaceAllString(values[tok.placeholder], "_"))
+	}
+
+	name := strings.Trim(sb.String(), "._ -")
+	if !strings.Contains(tmpl, "{codec_ext}") {
+		name += "." + f.CodecExt
+	}
+	return name
+}
+
+// compileNameTemplate converts a template into an anchored regex with one named group per
+// placeholder. Separator runs are optional so that names rendered with empty fields match.
+func compileNameTemplate(tmpl string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	seen := map[string]bool{}
+	for _, tok := range tokenizeTemplate(tmpl) {
+		switch {
+		case tok.placeholder != "" && !seen[tok.placeholder]:
+			seen[tok.placeholder] = true
+			fmt.Fprintf(&sb, "(?P<%s>%s)", tok.placeholder, templatePlaceholders[tok.placeholder])
+		case tok.placeholder != "":
+			sb.WriteString(templatePlaceholders[tok.placeholder])
+		case separatorRe.MatchString(tok.literal):
+			sb.WriteString(`[._ -]*`)
+		default:
+			sb.WriteString(regexp.QuoteMeta(tok.literal))
+		}
+	}
+	if !strings.Contains(tmpl, "{codec_ext}") {
+		sb.WriteString(`\.[A-Za-z0-9]+`)
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+func tokenizeTemplate(tmpl string) []templateToken {
+	var tokens []templateToken
+	last := 0
+	for _, loc := range placeholderRe.FindAllStringSubmatchIndex(tmpl, -1) {
+		if loc[0] > last {
+			tokens = append(tokens, templateToken{literal: tmpl[last:loc[0]]})
+		}
+		tokens = append(tokens, templateToken{placeholder: tmpl[loc[2]:loc[3]]})
+		last = loc[1]
+	}
+	if last < len(tmpl) {
+		tokens = append(tokens, templateToken{literal: tmpl[last:]})
+	}
+	return tokens
+}
+
+// episodeNameFields returns the show, season and episode fields for a video
+func episodeNameFields(rel Release, ok bool) NameFields {
+	if !ok {
+		return NameFields{Episode: "XX"}
+	}
+	f := NameFields{Show: rel.Title, Episode: episodeField(rel.Episode, rel.Episode.Key())}
+	if rel.Episode.Season > 0 && !rel.Episode.Special {
+		f.Season = fmt.Sprintf("%02d", rel.Episode.Season)
+	}
+	return f
+}
+
+// episodeField strips the season prefix from an episode key ("S01E01-E02" → "01-E02")
+func episodeField(ep EpisodeID, key string) string {
+	if ep.Season > 0 && !ep.Special {
+		return strings.TrimPrefix(key, fmt.Sprintf("S%02dE", ep.Season))
+	}
+	return key
+}
+
+// trackNameFields completes the episode fields with the properties of an extracted track
+func trackNameFields(base NameFields, t Track, lang string, forced bool, ext string) NameFields {
+	f := base
+	f.Lang = lang
+	f.TrackID = fmt.Sprintf("%d", t.ID)
+	f.TrackName = t.Props.TrackName
+	if forced {
+		f.Forced = "forced"
+	}
+	f.CodecExt = strings.TrimPrefix(ext, ".")
+	return f
+}
+
+// findTemplateFile searches dir for files whose name, parsed with the template, belongs to
+// the video's episode (full range or first episode) and language. The newest version of
+// the main track wins.
+func findTemplateFile(dir, tmpl string, rel Release, lang string, acceptExt func(ext string) bool) (string, []string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil
+	}
+
+	re := compileNameTemplate(tmpl)
+	want := episodeNameFields(rel, true)
+	first := episodeField(rel.Episode, rel.Episode.FirstKey())
+
+	// Files named after the full range win over files named after its first episode
+	for _, target := range []string{want.Episode, first} {
+		var candidates []episodeCandidate
+		for _, f := range entries {
+			if f.IsDir() || !acceptExt(strings.ToLower(filepath.Ext(f.Name()))) {
+				continue
+			}
+			m := re.FindStringSubmatch(f.Name())
+			if m == nil {
+				continue
+			}
+			groups := map[string]string{}
+			for i, name := range re.SubexpNames() {
+				if name != "" {
+					groups[name] = m[i]
+				}
+			}
+
+			episode, version := splitVersion(groups["episode"])
+			if !strings.EqualFold(episode, target) {
+				continue
+			}
+			if s, ok := groups["season"]; ok && s != "" && want.Season != "" && atoi(s) != atoi(want.Season) {
+				continue
+			}
+			if l, ok := groups["lang"]; ok && !strings.EqualFold(l, lang) {
+				continue
+			}
+
+			variant := strings.Join([]string{groups["track_id"], groups["track_name"], groups["forced"]}, "_")
+			c := episodeCandidate{name: f.Name(), version: version, variant: variant}
+			if fi, err := f.Info(); err == nil {
+				c.modTime = fi.ModTime()
+			}
+			candidates = append(candidates, c)
+		}
+		if len(candidates) > 0 {
+			return pickCandidate(dir, candidates)
+		}
+	}
+	return "", nil
+}
+
+// splitVersion separates a release version from an episode field ("05v2" → "05", 2)
+func splitVersion(episode string) (string, int) {
+	loc := episodeVersionRe.FindAllStringSubmatchIndex(episode, -1)
+	if loc == nil {
+		return episode, 1
+	}
+	version := 1
+	var sb strings.Builder
+	last := 0
+	for _, l := range loc {
+		sb.WriteString(episode[last:l[0]])
+		version = max(version, atoi(episode[l[2]:l[3]]))
+		last = l[1]
+	}
+	sb.WriteString(episode[last:])
+	return sb.String(), version
+}
@@ -0,0 +1,105 @@
+package mkv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidateNameTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl  string
+		valid bool
+	}{
+		{"{show} - {episode}_{lang}", true},
+		{"{show} S{season}E{episode}.{lang}.{forced}.{codec_ext}", true},
+		{"{show}_{lang}", false},           // no episode
+		{"{episode}_{language}", false},    // unknown placeholder
+		{"{show}/{episode}_{lang}", false}, // path separator
+	}
+
+	for _, tt := range tests {
+		err := ValidateNameTemplate(tt.tmpl)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateNameTemplate(%q) error = %v; want valid=%v", tt.tmpl, err, tt.valid)
+		}
+	}
+}
+
+func TestRenderName(t *testing.T) {
+	fields := NameFields{Show: "My Show", Season: "01", Episode: "05", Lang: "ita", TrackID: "3", TrackName: "Signs/Songs", CodecExt: "ass"}
+
+	tests := []struct {
+		tmpl     string
+		forced   string
+		expected string
+	}{
+		{"{show} - S{season}E{episode}_{lang}", "", "My Show - S01E05_ita.ass"},
+		{"{episode}_{lang}_{forced}", "forced", "05_ita_forced.ass"},
+		{"{episode}_{lang}_{forced}", "", "05_ita.ass"},
+		{"{episode}.{lang}.{forced}.{codec_ext}", "", "05.ita.ass"},
+		{"{episode}_{track_id}_{track_name}", "", "05_3_Signs_Songs.ass"},
+	}
+
+	for _, tt := range tests {
+		f := fields
+		f.Forced = tt.forced
+		if got := RenderName(tt.tmpl, f); got != tt.expected {
+			t.Errorf("RenderName(%q) = %q; want %q", tt.tmpl, got, tt.expected)
+		}
+	}
+}
+
+func TestNameTemplateRoundTrip(t *testing.T) {
+	tmpl := "{show} - S{season}E{episode}.{lang}.{forced}"
+	dir := t.TempDir()
+
+	videos := []string{"[Grp] My Show S01E05 [1080p].mkv", "[Grp] My Show S01E06 [1080p].mkv", "[Grp] My Show S02E05 [1080p].mkv"}
+	for _, video := range videos {
+		rel, ok := ParseRelease(video, nil)
+		f := episodeNameFields(rel, ok)
+		f.Lang, f.CodecExt = "ita", "ass"
+		name := RenderName(tmpl, f)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	for _, video := range videos {
+		rel, _ := ParseRelease(video, nil)
+		got, _ := findTemplateFile(dir, tmpl, rel, "ita", isSubtitleExt)
+		want := RenderName(tmpl, NameFields{Show: "My Show", Season: rel.Episode.Key()[1:3], Episode: rel.Episode.Key()[4:], Lang: "ita", CodecExt: "ass"})
+		if filepath.Base(got) != want {
+			t.Errorf("findTemplateFile(%q) = %q; want %q", video, got, want)
+		}
+	}
+
+	// Wrong language is never matched
+	rel, _ := ParseRelease(videos[0], nil)
+	if got, _ := findTemplateFile(dir, tmpl, rel, "eng", isSubtitleExt); got != "" {
+		t.Errorf("findTemplateFile with eng = %q; want no match", got)
+	}
+}
+
+func TestFindTemplateFileKeepsMainTrack(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := "{show} - {episode}.{lang}{forced}"
+	now := time.Now()
+	for i, name := range []string{"My Show - 05.ita.ass", "My Show - 05.itaforced.ass"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		// The forced track is the newest file
+		mtime := now.Add(time.Duration(i) * time.Hour)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Failed to set file time: %v", err)
+		}
+	}
+
+	rel, _ := ParseRelease("[Group] My Show - 05 [1080p].mkv", nil)
+	if got, _ := findTemplateFile(dir, tmpl, rel, "ita", isSubtitleExt); filepath.Base(got) != "My Show - 05.ita.ass" {
+		t.Errorf("findTemplateFile = %q; want the full track", got)
+	}
+}
@@ -0,0 +1,406 @@
+package mkv
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Cluster element IDs read when rebuilding text subtitles
+const (
+	idClusterTimestamp = 0xE7
+	idSimpleBlock      = 0xA3
+	idBlockGroup       = 0xA0
+	idBlock            = 0xA1
+	idBlockDuration    = 0x9B
+	idBlockAdditions   = 0x75A1
+	idBlockMore        = 0xA6
+	idBlockAddID       = 0xEE
+	idBlockAdditional  = 0xA5
+)
+
+const utf8BOM = "\xEF\xBB\xBF"
+
+// defaultASSFormat is the [Events] format used when a track's CodecPrivate lacks one
+const defaultASSFormat = "Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text"
+
+// isTextCodecID reports whether a subtitle track can be rebuilt by extractTextNative
+func isTextCodecID(codecID string) bool {
+	switch codecID {
+	case "S_TEXT/UTF8", "S_TEXT/ASCII", "S_TEXT/ASS", "S_TEXT/SSA", "S_ASS", "S_SSA", "S_TEXT/WEBVTT":
+		return true
+	}
+	return false
+}
+
+// textCue is a subtitle block with its absolute timing in nanoseconds
+type textCue struct {
+	start, end int64
+	data       []byte
+	addition   []byte // BlockAdditional: WebVTT cue settings, identifier and comments
+}
+
+// extractTextNative rebuilds text subtitle tracks and attachments from the Matroska
+// file itself, without mkvextract. outputs maps track IDs to the files to write.
+// Nothing is written unless the whole file could be read.
+func extractTextNative(path string, outputs map[int]string, fonts []fontJob) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	m, err := readMatroska(f)
+	if err != nil {
+		return err
+	}
+
+	// Map the selected track IDs to the track numbers used by the blocks
+	wanted := map[uint64]*Track{}
+	for id := range outputs {
+		if id < 0 || id >= len(m.info.Tracks) {
+			return fmt.Errorf("track %d not found", id)
+		}
+		t := &m.info.Tracks[id]
+		if !isTextCodecID(t.Props.CodecID) {
+			return fmt.Errorf("track %d is not a text subtitle track", id)
+		}
+		wanted[t.Props.Number] = t
+	}
+
+	cues, err := m.readCues(wanted)
+	if err != nil {
+		return err
+	}
+
+	for number, t := range wanted {
+		codec := m.codecs[number]
+		var data []byte
+		switch t.Props.CodecID {
+		case "S_TEXT/ASS", "S_TEXT/SSA", "S_ASS", "S_SSA":
+			data = renderASS(codec.private, cues[number])
+		case "S_TEXT/WEBVTT":
+			data = renderWebVTT(codec.private, cues[number])
+		default:
+			data = renderSRT(cues[number])
+		}
+		if err := os.WriteFile(outputs[t.ID], data, 0644); err != nil {
+			return err
+		}
+	}
+
+	for _, job := range fonts {
+		if err := m.writeAttachment(job.attachment.ID, job.tmpPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCues scans the clusters and collects the blocks of the wanted tracks
+func (m *matroskaInfo) readCues(wanted map[uint64]*Track) (map[uint64][]textCue, error) {
+	cues := map[uint64][]textCue{}
+	if m.firstCluster < 0 {
+		return cues, nil
+	}
+
+	r := m.reader
+	if err := r.seek(m.firstCluster); err != nil {
+		return nil, err
+	}
+	var clusterTimestamp int64
+	for m.segment.size == unknownSize || r.pos < m.segment.end() {
+		el, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch el.id {
+		case idCluster:
+			continue // The children of the cluster follow
+		case idClusterTimestamp:
+			data, err := r.body(el)
+			if err != nil {
+				return nil, err
+			}
+			clusterTimestamp = int64(ebmlUint(data))
+			continue
+		case idSimpleBlock:
+			if err := m.readBlock(el, clusterTimestamp, -1, nil, wanted, cues); err != nil {
+				return nil, err
+			}
+		case idBlockGroup:
+			var block ebmlElement
+			var addition []byte
+			duration := int64(-1)
+			err := r.children(el, func(child ebmlElement) error {
+				switch child.id {
+				case idBlock:
+					block = child
+				case idBlockDuration:
+					data, err := r.body(child)
+					if err != nil {
+						return err
+					}
+					duration = int64(ebmlUint(data))
+				case idBlockAdditions:
+					data, err := r.body(child)
+					if err != nil {
+						return err
+					}
+					if addition, err = blockAdditional(data); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			if block.size > 0 {
+				if err := m.readBlock(block, clusterTimestamp, duration, addition, wanted, cues); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := r.skip(el); err != nil {
+			return nil, err
+		}
+	}
+	return cues, nil
+}
+
+// blockAdditional returns the data of the BlockMore with BlockAddID 1 (the default)
+func blockAdditional(data []byte) ([]byte, error) {
+	mores, err := parseChildren(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, more := range mores {
+		if more.id != idBlockMore {
+			continue
+		}
+		fields, err := parseChildren(more.data)
+		if err != nil {
+			return nil, err
+		}
+		addID, additional := uint64(1), []byte(nil)
+		for _, f := range fields {
+			switch f.id {
+			case idBlockAddID:
+				addID = ebmlUint(f.data)
+			case idBlockAdditional:
+				additional = f.data
+			}
+		}
+		if addID == 1 {
+			return additional, nil
+		}
+	}
+	return nil, nil
+}
+
+// readBlock decodes a (Simple)Block if it belongs to a wanted track. duration is in
+// timestamp units, -1 when the block carries none.
+func (m *matroskaInfo) readBlock(el ebmlElement, clusterTimestamp, duration int64, addition []byte, wanted map[uint64]*Track, cues map[uint64][]textCue) error {
+	r := m.reader
+	if err := r.seek(el.offset); err != nil {
+		return err
+	}
+	number, n, err := r.readVint(false)
+	if err != nil {
+		return err
+	}
+	if wanted[number] == nil {
+		return nil
+	}
+
+	data, err := r.body(el)
+	if err != nil {
+		return err
+	}
+	if len(data) < n+3 {
+		return fmt.Errorf("truncated block at offset %d", el.offset)
+	}
+	relative := int64(int16(uint16(data[n])<<8 | uint16(data[n+1])))
+	if flags := data[n+2]; flags&0x06 != 0 {
+		return fmt.Errorf("laced subtitle blocks are not supported")
+	}
+	frame, err := m.codecs[number].decode(data[n+3:])
+	if err != nil {
+		return err
+	}
+
+	start := (clusterTimestamp + relative) * int64(m.scale)
+	end := start + int64(m.codecs[number].defaultDuration)
+	if duration >= 0 {
+		end = start + duration*int64(m.scale)
+	}
+	cues[number] = append(cues[number], textCue{start: start, end: end, data: frame, addition: addition})
+	return nil
+}
+
+// decode undoes the frame compression of the track
+func (c *trackCodec) decode(frame []byte) ([]byte, error) {
+	if c.compression == nil {
+		return frame, nil
+	}
+	switch c.compression.algo {
+	case 0:
+		zr, err := zlib.NewReader(bytes.NewReader(frame))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	case 3:
+		return append(append([]byte{}, c.compression.settings...), frame...), nil
+	}
+	return nil, fmt.Errorf("unsupported compression algorithm %d", c.compression.algo)
+}
+
+// writeAttachment copies the data of an attached file to path
+func (m *matroskaInfo) writeAttachment(id int, path string) error {
+	if id < 1 || id > len(m.files) {
+		return fmt.Errorf("attachment %d not found", id)
+	}
+	r := m.reader
+	var data ebmlElement
+	err := r.children(m.files[id-1], func(child ebmlElement) error {
+		if child.id == idFileData {
+			data = child
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := r.seek(data.offset); err != nil {
+		return err
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(out, r.r, data.size); err != nil {
+		out.Close()
+		return err
+	}
+	r.pos += data.size
+	return out.Close()
+}
+
+// renderSRT writes cues the way mkvextract does: UTF-8 BOM, numbered entries,
+// trailing whitespace of each text removed
+func renderSRT(cues []textCue) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(utf8BOM)
+	for i, c := range cues {
+		fmt.Fprintf(&buf, "%d\n%s --> %s\n%s\n\n", i+1,
+			formatCueTime(c.start, ','), formatCueTime(c.end, ','), strings.TrimRight(string(c.data), " \t\r\n"))
+	}
+	return buf.Bytes()
+}
+
+// renderASS rebuilds an ASS/SSA script from the header in CodecPrivate and the
+// blocks, which hold "ReadOrder,Layer,Style,Name,...,Text" and are sorted by ReadOrder
+func renderASS(private []byte, cues []textCue) []byte {
+	type event struct {
+		order int
+		line  string
+	}
+	events := make([]event, 0, len(cues))
+	for _, c := range cues {
+		fields := strings.SplitN(strings.TrimRight(string(c.data), "\r\n"), ",", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		order, _ := strconv.Atoi(fields[0])
+		line := fmt.Sprintf("Dialogue: %s,%s,%s,%s", fields[1], formatASSTime(c.start), formatASSTime(c.end), fields[2])
+		events = append(events, event{order: order, line: line})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].order < events[j].order })
+
+	header := strings.TrimPrefix(string(private), utf8BOM)
+	if !strings.HasSuffix(header, "\n") {
+		header += "\n"
+	}
+	if !strings.Contains(header, "[Events]") {
+		header += "\n[Events]\n" + defaultASSFormat + "\n"
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(utf8BOM)
+	buf.WriteString(header)
+	for _, e := range events {
+		buf.WriteString(e.line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// renderWebVTT writes the header stored in CodecPrivate followed by the cues the way
+// mkvextract does. The BlockAdditional of a cue holds its settings, its identifier and
+// the comment blocks preceding it, one per line.
+func renderWebVTT(private []byte, cues []textCue) []byte {
+	header := strings.TrimRight(strings.TrimPrefix(string(private), utf8BOM), "\r\n")
+	if header == "" {
+		header = "WEBVTT"
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	buf.WriteByte('\n')
+	for _, c := range cues {
+		var settings, id, comments string
+		if c.addition != nil {
+			parts := strings.SplitN(string(c.addition), "\n", 3)
+			settings = strings.TrimSpace(parts[0])
+			if len(parts) > 1 {
+				id = strings.TrimSpace(parts[1])
+			}
+			if len(parts) > 2 {
+				comments = strings.Trim(parts[2], "\r\n")
+			}
+		}
+
+		buf.WriteByte('\n')
+		if comments != "" {
+			buf.WriteString(comments)
+			buf.WriteString("\n\n")
+		}
+		if id != "" {
+			buf.WriteString(id)
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(formatCueTime(c.start, '.') + " --> " + formatCueTime(c.end, '.'))
+		if settings != "" {
+			buf.WriteString(" " + settings)
+		}
+		buf.WriteByte('\n')
+		buf.WriteString(strings.TrimRight(string(c.data), " \t\r\n"))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// formatCueTime formats nanoseconds as HH:MM:SS,mmm (SRT) or HH:MM:SS.mmm (WebVTT)
+func formatCueTime(ns int64, sep byte) string {
+	ms := ns / 1000000
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// formatASSTime formats nanoseconds as H:MM:SS.cc
+func formatASSTime(ns int64) string {
+	cs := ns / 10000000
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
@@ -0,0 +1,200 @@
+package mkv
+
+import (
+	"bytes"
+	"compress/zlib"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// record regenerates the testdata fixtures with MKVToolNix:
+//
+//	go test ./internal/mkv -run 'TestExtractTextNative|TestReadMatroskaInfo' -record
+var record = flag.Bool("record", false, "record testdata fixtures with mkvmerge and mkvextract")
+
+// fixture reads a file of testdata. With -record, it is first regenerated by running
+// the MKVToolNix command whose output it holds in dir, so that the file names it
+// prints don't depend on the temporary directory of the test.
+func fixture(t *testing.T, name, dir, command string, args ...string) []byte {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *record {
+		cmd := exec.Command(command, args...)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("Failed to record %s with %s: %v", name, command, err)
+		}
+		if command == "mkvmerge" {
+			// mkvmerge -J prints the fixture; mkvextract writes the files itself
+			if err := os.WriteFile(path, out, 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	return data
+}
+
+// ebmlBlock encodes the body of a Block/SimpleBlock for a track number below 127
+func ebmlBlock(number int, relative int16, data string) []byte {
+	return append([]byte{0x80 | byte(number), byte(uint16(relative) >> 8), byte(relative), 0x80}, data...)
+}
+
+func zlibCompress(t *testing.T, data string) string {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	zw.Close()
+	return buf.String()
+}
+
+// buildSubtitleMKV writes a file with a video track, a zlib-compressed SRT track,
+// an ASS track whose events are stored out of order and a WebVTT track whose cue
+// settings, identifier and comments are stored in BlockAdditions
+func buildSubtitleMKV(t *testing.T) string {
+	assHeader := "[Script Info]\nScriptType: v4.00+\n\n[V4+ Styles]\n" +
+		"Format: Name, Fontname, Fontsize\nStyle: Default,Roboto,48\n\n[Events]\n" + defaultASSFormat + "\n"
+	zlibEncoding := ebmlEl(idContentEncodings, ebmlEl(idContentEncoding,
+		ebmlEl(idContentCompression, ebmlU(idContentCompAlgo, 0))))
+
+	tracks := ebmlEl(idTracks,
+		ebmlEl(idTrackEntry, ebmlU(idTrackNumber, 1), ebmlU(idTrackUID, 1), ebmlU(idTrackType, 1),
+			ebmlS(idCodecID, "V_MPEG4/ISO/AVC")),
+		ebmlEl(idTrackEntry, ebmlU(idTrackNumber, 2), ebmlU(idTrackUID, 2), ebmlU(idTrackType, 0x11),
+			ebmlS(idCodecID, "S_TEXT/UTF8"), ebmlS(idLanguage, "ita"), zlibEncoding),
+		ebmlEl(idTrackEntry, ebmlU(idTrackNumber, 3), ebmlU(idTrackUID, 3), ebmlU(idTrackType, 0x11),
+			ebmlS(idCodecID, "S_TEXT/ASS"), ebmlS(idLanguage, "eng"), ebmlS(idCodecPrivate, assHeader)),
+		ebmlEl(idTrackEntry, ebmlU(idTrackNumber, 4), ebmlU(idTrackUID, 4), ebmlU(idTrackType, 0x11),
+			ebmlS(idCodecID, "S_TEXT/WEBVTT"), ebmlS(idLanguage, "spa"), ebmlS(idCodecPrivate, "WEBVTT\n")),
+	)
+
+	clusters := [][]byte{
+		ebmlEl(idCluster,
+			ebmlU(idClusterTimestamp, 0),
+			ebmlEl(idSimpleBlock, ebmlBlock(1, 0, "video frame")),
+			ebmlEl(idBlockGroup, ebmlEl(idBlock, ebmlBlock(2, 1000, zlibCompress(t, "Ciao!\n"))), ebmlU(idBlockDuration, 1500)),
+			ebmlEl(idBlockGroup, ebmlEl(idBlock, ebmlBlock(3, 1000, "1,0,Default,,0,0,0,,Second line")), ebmlU(idBlockDuration, 2000)),
+			ebmlEl(idBlockGroup, ebmlEl(idBlock, ebmlBlock(3, 1000, "0,0,Default,,0,0,0,,{\\i1}First line")), ebmlU(idBlockDuration, 1000)),
+			ebmlEl(idBlockGroup, ebmlEl(idBlock, ebmlBlock(4, 1000, "Hola.")), ebmlU(idBlockDuration, 1500)),
+		),
+		ebmlEl(idCluster,
+			ebmlU(idClusterTimestamp, 30000),
+			ebmlEl(idSimpleBlock, ebmlBlock(1, 0, "video frame")),
+			ebmlEl(idBlockGroup, ebmlEl(idBlock, ebmlBlock(2, 2500, zlibCompress(t, "<i>Come stai?</i>"))), ebmlU(idBlockDuration, 2000)),
+			ebmlEl(idBlockGroup, ebmlEl(idBlock, ebmlBlock(4, 2500, "¿Qué tal?")), ebmlU(idBlockDuration, 2000),
+				ebmlEl(idBlockAdditions, ebmlEl(idBlockMore, ebmlU(idBlockAddID, 1),
+					ebmlS(idBlockAdditional, "align:start line:0\ngreeting\nNOTE Translated from Japanese")))),
+		),
+	}
+
+	file := bytes.Join([][]byte{
+		ebmlEl(idEBML, ebmlS(idDocType, "matroska")),
+		ebmlEl(idSegment, append([][]byte{ebmlEl(idSegmentInfo, ebmlU(idTimestampScale, 1000000)), tracks}, clusters...)...),
+	}, nil)
+
+	path := filepath.Join(t.TempDir(), "subs.mkv")
+	if err := os.WriteFile(path, file, 0644); err != nil {
+		t.Fatalf("Failed to write sample MKV: %v", err)
+	}
+	return path
+}
+
+func TestExtractTextNative(t *testing.T) {
+	path := buildSubtitleMKV(t)
+	outDir := t.TempDir()
+	outputs := map[int]string{
+		1: filepath.Join(outDir, "sample.srt"),
+		2: filepath.Join(outDir, "sample.ass"),
+		3: filepath.Join(outDir, "sample.vtt"),
+	}
+	if err := extractTextNative(path, outputs, nil); err != nil {
+		t.Fatalf("extractTextNative failed: %v", err)
+	}
+
+	// Expected mkvextract output for the same file
+	testdata, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatalf("Failed to resolve testdata: %v", err)
+	}
+	for id, output := range outputs {
+		got, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		name := filepath.Base(output)
+		want := fixture(t, name, filepath.Dir(path), "mkvextract", filepath.Base(path),
+			"tracks", fmt.Sprintf("%d:%s", id, filepath.Join(testdata, name)))
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from mkvextract output:\n got %q\nwant %q", name, got, want)
+		}
+	}
+
+	// Video tracks can only be extracted by mkvextract
+	if err := extractTextNative(path, map[int]string{0: filepath.Join(outDir, "video.h264")}, nil); err == nil {
+		t.Errorf("extractTextNative of a video track expected an error")
+	}
+}
+
+func TestExtractBatchFallback(t *testing.T) {
+	// Not a Matroska file: native extraction fails and mkvextract is tried
+	path := filepath.Join(t.TempDir(), "broken.mkv")
+	if err := os.WriteFile(path, []byte("not matroska"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	srt := Track{ID: 2}
+	srt.Props.CodecID = "S_TEXT/UTF8"
+
+	var batch extractBatch
+	batch.addTrack(srt, "subtitle", filepath.Join(t.TempDir(), "05_ita_2.srt"), "ita")
+	err := batch.run(path)
+	if err == nil || batch.fallback == nil {
+		t.Fatalf("run = %v, fallback %v; want both to fail", err, batch.fallback)
+	}
+	if !strings.Contains(err.Error(), "native extraction") {
+		t.Errorf("run error %q should mention the native failure", err)
+	}
+}
+
+func TestExtractBatchTextOnly(t *testing.T) {
+	srt, pgs := Track{ID: 2}, Track{ID: 3}
+	srt.Props.CodecID = "S_TEXT/UTF8"
+	pgs.Props.CodecID = "S_HDMV/PGS"
+
+	var batch extractBatch
+	batch.addTrack(srt, "subtitle", "05_ita_2.srt", "ita")
+	if !batch.textOnly() {
+		t.Errorf("a batch of SRT tracks should be extracted natively")
+	}
+	batch.chapters = "05_chapters.xml"
+	if batch.textOnly() {
+		t.Errorf("chapters require mkvextract")
+	}
+	batch.chapters = ""
+	batch.addTrack(pgs, "subtitle", "05_ita_3.sup", "ita")
+	if batch.textOnly() {
+		t.Errorf("PGS tracks require mkvextract")
+	}
+}
+
+func TestExtractTextNativeAttachments(t *testing.T) {
+	outDir := t.TempDir()
+	font := fontJob{attachment: Attachment{ID: 1}, tmpPath: filepath.Join(outDir, ".sample.1.tmp")}
+	outputs := map[int]string{2: filepath.Join(outDir, "signs.ass")}
+	if err := extractTextNative(buildSampleMKV(t), outputs, []fontJob{font}); err != nil {
+		t.Fatalf("extractTextNative failed: %v", err)
+	}
+	if info, err := os.Stat(font.tmpPath); err != nil || info.Size() != 1000 {
+		t.Errorf("attachment not written correctly: %v", err)
+	}
+}
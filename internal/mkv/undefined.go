@@ -0,0 +1,206 @@
+package mkv
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// undefinedLang is the code mkvmerge reports for tracks without a language
+const undefinedLang = "und"
+
+// ValidateUndefinedPolicy checks a --undefined value: ignore, include, guess or as:<lang>
+func ValidateUndefinedPolicy(policy string) error {
+	switch {
+	case policy == "ignore", policy == "include", policy == "guess":
+		return nil
+	case strings.HasPrefix(policy, "as:"):
+		lang := strings.TrimSpace(strings.TrimPrefix(policy, "as:"))
+		if lang == "" {
+			return fmt.Errorf("missing language after \"as:\"")
+		}
+		_, err := NormalizeLanguage(lang)
+		return err
+	}
+	return fmt.Errorf("unknown policy %q (use ignore, include, guess or as:<lang>)", policy)
+}
+
+// undefinedTarget returns the requested language an undefined track is extracted as,
+// or "" when the policy drops it. "include" and an unsuccessful "guess" assign the
+// track to the first requested language, so it is extracted only once.
+func undefinedTarget(policy string, languages []string) string {
+	if len(languages) == 0 {
+		return ""
+	}
+	switch {
+	case policy == "ignore":
+		return ""
+	case strings.HasPrefix(policy, "as:"):
+		lang := canonicalLanguage(strings.TrimSpace(strings.TrimPrefix(policy, "as:")))
+		if slices.Contains(languages, lang) {
+			return lang
+		}
+		return ""
+	}
+	return languages[0]
+}
+
+// isTextSubtitleExt reports whether a subtitle extension can be read by guessLanguage
+func isTextSubtitleExt(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".srt", ".ass", ".ssa", ".vtt":
+		return true
+	}
+	return false
+}
+
+var (
+	// SRT/WebVTT cue timings and numeric cue identifiers
+	cueLineRe = regexp.MustCompile(`^(\d+|.*-->.*|WEBVTT.*)$`)
+	// ASS override blocks {\i1} and markup tags <i>
+	subtitleTagRe = regexp.MustCompile(`\{[^}]*\}|<[^>]*>`)
+)
+
+// subtitleText returns the dialogue text of an SRT, WebVTT or ASS/SSA file
+func subtitleText(data []byte, ext string) string {
+	var sb strings.Builder
+	ass := strings.EqualFold(ext, ".ass") || strings.EqualFold(ext, ".ssa")
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if ass {
+			if !strings.HasPrefix(line, "Dialogue:") {
+				continue
+			}
+			// The text is the tenth comma-separated field
+			fields := strings.SplitN(line, ",", 10)
+			if len(fields) < 10 {
+				continue
+			}
+			line = strings.ReplaceAll(fields[9], `\N`, " ")
+		} else if line == "" || cueLineRe.MatchString(line) {
+			continue
+		}
+		sb.WriteString(subtitleTagRe.ReplaceAllString(line, " "))
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+// Short samples of everyday dialogue used to build the trigram profile of each language
+var languageSamples = map[string]string{
+	"ita": "non lo so che cosa vuoi dire ma io sono qui per te. andiamo via di qua, non c'è più tempo. " +
+		"che cosa stai facendo? lascia stare, ci penso io. mi dispiace, non volevo. grazie per tutto quello che hai fatto. " +
+		"perché non me l'hai detto prima? questa è la nostra ultima possibilità. dove sei stato tutto questo tempo? " +
+		"ho bisogno del tuo aiuto, per favore. sono stanco di aspettare, dobbiamo andare adesso.",
+	"eng": "i don't know what you mean but i am here for you. let's get out of here, there is no more time. " +
+		"what are you doing? leave it, i'll take care of it. i'm sorry, i didn't mean to. thank you for everything you have done. " +
+		"why didn't you tell me before? this is our last chance. where have you been all this time? " +
+		"i need your help, please. i'm tired of waiting, we have to go now.",
+	"spa": "no sé lo que quieres decir pero estoy aquí para ti. vámonos de aquí, ya no hay tiempo. " +
+		"¿qué estás haciendo? déjalo, yo me encargo. lo siento, no quería. gracias por todo lo que has hecho. " +
+		"¿por qué no me lo dijiste antes? esta es nuestra última oportunidad. ¿dónde has estado todo este tiempo? " +
+		"necesito tu ayuda, por favor. estoy cansado de esperar, tenemos que irnos ahora.",
+	"por": "eu não sei o que você quer dizer mas eu estou aqui para você. vamos embora daqui, não há mais tempo. " +
+		"o que você está fazendo? deixa, eu cuido disso. desculpa, eu não queria. obrigado por tudo o que você fez. " +
+		"por que você não me disse antes? esta é a nossa última chance. onde você esteve todo esse tempo? " +
+		"preciso da sua ajuda, por favor. estou cansado de esperar, temos que ir agora.",
+	"fre": "je ne sais pas ce que tu veux dire mais je suis là pour toi. partons d'ici, il n'y a plus de temps. " +
+		"qu'est-ce que tu fais? laisse, je m'en occupe. je suis désolé, je ne voulais pas. merci pour tout ce que tu as fait. " +
+		"pourquoi tu ne me l'as pas dit avant? c'est notre dernière chance. où étais-tu pendant tout ce temps? " +
+		"j'ai besoin de ton aide, s'il te plaît. je suis fatigué d'attendre, nous devons partir maintenant.",
+	"ger": "ich weiß nicht, was du meinst, aber ich bin für dich da. lass uns hier verschwinden, es ist keine zeit mehr. " +
+		"was machst du da? lass es, ich kümmere mich darum. es tut mir leid, das wollte ich nicht. danke für alles, was du getan hast. " +
+		"warum hast du es mir nicht früher gesagt? das ist unsere letzte chance. wo warst du die ganze zeit? " +
+		"ich brauche deine hilfe, bitte. ich bin es leid zu warten, wir müssen jetzt gehen.",
+}
+
+// Thresholds of guessLanguage. Scores are cosine similarities (0-1) between the trigram
+// profile of the text and that of each language.
+const (
+	minGuessLetters = 200  // Amount of text needed before a guess is trusted
+	minGuessScore   = 0.25 // Below this, the text is in none of the known languages
+	maxRunnerUp     = 0.8  // The second best language must score below this share of the best
+)
+
+var languageProfiles = func() map[string]map[string]float64 {
+	profiles := make(map[string]map[string]float64, len(languageSamples))
+	for lang, sample := range languageSamples {
+		profiles[lang] = trigramProfile(sample)
+	}
+	return profiles
+}()
+
+// trigramProfile returns the normalized character trigram frequencies of text,
+// with runs of non-letters collapsed to a single space
+func trigramProfile(text string) map[string]float64 {
+	var letters []rune
+	space := true
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) {
+			letters = append(letters, r)
+			space = false
+		} else if !space {
+			letters = append(letters, ' ')
+			space = true
+		}
+	}
+
+	counts := map[string]float64{}
+	for i := 0; i+3 <= len(letters); i++ {
+		counts[string(letters[i:i+3])]++
+	}
+	var norm float64
+	for _, c := range counts {
+		norm += c * c
+	}
+	norm = math.Sqrt(norm)
+	for g := range counts {
+		counts[g] /= norm
+	}
+	return counts
+}
+
+// guessLanguage returns the language of subtitle text, or "" when there is too
+// little text or no language stands out among the known profiles
+func guessLanguage(text string) string {
+	n := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+	if n < minGuessLetters {
+		return ""
+	}
+
+	profile := trigramProfile(text)
+	best, bestScore, second := "", 0.0, 0.0
+	for lang, ref := range languageProfiles {
+		var score float64
+		for g, w := range profile {
+			score += w * ref[g]
+		}
+		if score > bestScore {
+			best, bestScore, second = lang, score, bestScore
+		} else if score > second {
+			second = score
+		}
+	}
+	if bestScore < minGuessScore || second >= maxRunnerUp*bestScore {
+		return ""
+	}
+	return best
+}
+
+// guessFileLanguage guesses the language of an extracted text subtitle file
+func guessFileLanguage(path, ext string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return guessLanguage(subtitleText(data, ext))
+}
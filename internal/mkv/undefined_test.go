@@ -0,0 +1,103 @@
+package mkv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateUndefinedPolicy(t *testing.T) {
+	for _, policy := range []string{"ignore", "include", "guess", "as:eng"} {
+		if err := ValidateUndefinedPolicy(policy); err != nil {
+			t.Errorf("ValidateUndefinedPolicy(%q) = %v", policy, err)
+		}
+	}
+	for _, policy := range []string{"", "all", "as:"} {
+		if err := ValidateUndefinedPolicy(policy); err == nil {
+			t.Errorf("ValidateUndefinedPolicy(%q) expected an error", policy)
+		}
+	}
+}
+
+func TestUndefinedTarget(t *testing.T) {
+	languages := []string{"ita", "eng"}
+	tests := []struct {
+		policy   string
+		expected string
+	}{
+		{"include", "ita"},
+		{"guess", "ita"},
+		{"ignore", ""},
+		{"as:eng", "eng"},
+		{"as:jpn", ""},
+	}
+	for _, tt := range tests {
+		if got := undefinedTarget(tt.policy, languages); got != tt.expected {
+			t.Errorf("undefinedTarget(%q) = %q; want %q", tt.policy, got, tt.expected)
+		}
+	}
+}
+
+func TestSubtitleText(t *testing.T) {
+	srt := "1\n00:00:01,000 --> 00:00:02,000\n<i>Ciao a tutti</i>\n\n2\n00:00:03,000 --> 00:00:04,000\nCome state?\n"
+	if got := strings.Join(strings.Fields(subtitleText([]byte(srt), ".srt")), " "); got != "Ciao a tutti Come state?" {
+		t.Errorf("srt text = %q", got)
+	}
+
+	ass := "[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+		"Dialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,{\\i1}Hello, there{\\i0}\\NFriend\n"
+	if got := strings.Join(strings.Fields(subtitleText([]byte(ass), ".ass")), " "); got != "Hello, there Friend" {
+		t.Errorf("ass text = %q", got)
+	}
+}
+
+func TestGuessLanguage(t *testing.T) {
+	tests := map[string]string{
+		"ita": "Sei sicuro di volerlo fare? Non abbiamo ancora trovato la strada giusta e la notte sta arrivando. " +
+			"Ascoltami bene, domani mattina partiremo insieme e nessuno ci fermerà. Ho sempre creduto in te, " +
+			"anche quando gli altri dicevano che era impossibile. Adesso però dobbiamo restare calmi.",
+		"eng": "Are you sure you want to do this? We still haven't found the right way and the night is coming. " +
+			"Listen to me carefully, tomorrow morning we will leave together and nobody will stop us. I always believed in you, " +
+			"even when the others said it was impossible. But now we have to stay calm.",
+		"spa": "¿Estás seguro de que quieres hacerlo? Todavía no hemos encontrado el camino correcto y la noche está llegando. " +
+			"Escúchame bien, mañana por la mañana saldremos juntos y nadie nos detendrá. Siempre creí en ti, " +
+			"incluso cuando los demás decían que era imposible. Pero ahora tenemos que mantener la calma.",
+		"ger": "Bist du sicher, dass du das tun willst? Wir haben den richtigen Weg noch nicht gefunden und die Nacht kommt. " +
+			"Hör mir gut zu, morgen früh brechen wir zusammen auf und niemand wird uns aufhalten. Ich habe immer an dich geglaubt, " +
+			"auch als die anderen sagten, es sei unmöglich. Aber jetzt müssen wir ruhig bleiben.",
+	}
+	for expected, text := range tests {
+		if got := guessLanguage(text); got != expected {
+			t.Errorf("guessLanguage(%.30q...) = %q; want %q", text, got, expected)
+		}
+	}
+
+	if got := guessLanguage("Ciao!"); got != "" {
+		t.Errorf("guessLanguage on short text = %q; want no guess", got)
+	}
+}
+
+func TestGuessLanguageNearTies(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"portuguese", "Você tem certeza de que quer fazer isso? Ainda não encontramos o caminho certo e a noite está chegando. " +
+			"Escute bem, amanhã de manhã vamos sair juntos e ninguém vai nos parar. Eu sempre acreditei em você, " +
+			"mesmo quando os outros diziam que era impossível. Mas agora precisamos ficar calmos.", "por"},
+		{"spanish and portuguese", "¿Estás seguro de que quieres hacerlo? Todavía no hemos encontrado el camino correcto. " +
+			"Você tem certeza de que quer fazer isso? Ainda não encontramos o caminho certo e a noite está chegando. " +
+			"Escúchame bien, mañana saldremos juntos y nadie nos detendrá. Escute bem, amanhã vamos sair juntos e ninguém vai nos parar.", ""},
+		{"italian and english", "Sei sicuro di volerlo fare? Non abbiamo ancora trovato la strada giusta e la notte sta arrivando. " +
+			"Are you sure you want to do this? We still haven't found the right way and the night is coming. " +
+			"Ascoltami bene, domani mattina partiremo insieme. Listen to me carefully, tomorrow morning we will leave together.", ""},
+		{"romaji", "Nani wo itteru no ka wakaranai kedo, watashi wa koko ni iru yo. Hayaku ikou, mou jikan ga nai. " +
+			"Nani wo shiteru no? Makasete. Gomen ne, sonna tsumori ja nakatta. Zenbu arigatou. " +
+			"Doushite mae ni itte kurenakatta no? Kore ga saigo no chansu da. Zutto doko ni ita no?", ""},
+	}
+	for _, tt := range tests {
+		if got := guessLanguage(tt.text); got != tt.expected {
+			t.Errorf("guessLanguage(%s) = %q; want %q", tt.name, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package mkv
+
+import (
+	"bufio"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"mkvtea/internal/config"
+	"os"
+	"path/filepath"
+)
+
+// RunVerify checks a file against the CRC32 embedded in its name ([ABCD1234]).
+// Files without a CRC32 in their name are reported as skipped.
+func RunVerify(path string, cfg config.Config) (Result, error) {
+	expected := parseReleaseTags(filepath.Base(path)).CRC32
+	if expected == "" {
+		return Result{}, fmt.Errorf("skipped")
+	}
+
+	actual, err := fileCRC32(path)
+	if err != nil {
+		return Result{}, err
+	}
+	if actual != expected {
+		return Result{}, fmt.Errorf("CRC32 mismatch: expected %s, got %s", expected, actual)
+	}
+	return Result{}, nil
+}
+
+// fileCRC32 streams a file through CRC32 (IEEE) and returns the upper-case hex digest
+func fileCRC32(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, bufio.NewReaderSize(f, 1<<20)); err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+	return fmt.Sprintf("%08X", h.Sum32()), nil
+}
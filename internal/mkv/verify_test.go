@@ -0,0 +1,37 @@
+package mkv
+
+import (
+	"mkvtea/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunVerify(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("The quick brown fox jumps over the lazy dog")
+	// CRC32 (IEEE) of content is 414FA339
+	files := map[string]string{
+		"[Grp] Show - 01 [414FA339].mkv": "",
+		"[Grp] Show - 02 [414fa339].mkv": "",
+		"[Grp] Show - 03 [DEADBEEF].mkv": "CRC32 mismatch: expected DEADBEEF, got 414FA339",
+		"[Grp] Show - 04 [1080p].mkv":    "skipped",
+		"[Grp] Show - 05 (20231015).mkv": "skipped",
+	}
+
+	for name, expected := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		_, err := RunVerify(path, config.Config{})
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != expected {
+			t.Errorf("RunVerify(%q) = %q; want %q", name, got, expected)
+		}
+	}
+}
@@ -38,6 +38,7 @@ type ProcessModel struct {
 	// DRY-RUN tracking
 	extractedPaths []string // Paths where files would be extracted/merged
 	outputDir      string   // Final output directory for merge mode
+	languages      []string // Languages extracted across the library (--lang all)
 
 	// Concurrency
 	sem chan struct{}
@@ -51,6 +52,7 @@ type ProcessModel struct {
 	// Checkpoint tracking
 	checkpointMgr     *checkpoint.Manager
 	checkpointCounter int
+	resumed           bool // checkpointMgr holds a loaded checkpoint to continue
 }
 
 // NewProcessModel creates a new processor model
@@ -100,6 +102,35 @@ func (m *ProcessModel) logCheckpointWarningLocked(format string, args ...any) {
 	m.logs = append(m.logs, fmt.Sprintf("⚠️ CHECKPOINT: "+format, args...))
 }
 
+// resultLabels returns the names of the success, skipped and failed counters for the current mode
+func (m *ProcessModel) resultLabels() (success, skipped, failed string) {
+	if m.cfg.Mode == "verify" {
+		return "Match", "Unhashed", "Mismatch"
+	}
+	return "Success", "Skipped", "Failed"
+}
+
+// skipReason describes why a file was skipped in the current mode
+func (m *ProcessModel) skipReason() string {
+	if m.cfg.Mode == "verify" {
+		return "no CRC32 in file name"
+	}
+	return "no assets found"
+}
+
+// saveCheckpointOnExit persists progress when the user interrupts the run,
+// so that the next run can resume from here
+func (m *ProcessModel) saveCheckpointOnExit() {
+	if m.cfg.CheckpointInterval <= 0 || m.checkpointMgr == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkpointMgr.Save(); err != nil {
+		m.logCheckpointWarningLocked("failed to save checkpoint: %v", err)
+	}
+}
+
 func (m *ProcessModel) Init() tea.Cmd {
 	return tea.Batch(m.spinner.Tick, m.startProcessing())
 }
@@ -130,6 +161,9 @@ func (m *ProcessModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
 		if msg.String() == "ctrl+c" || msg.String() == "q" {
+			if !m.finished {
+				m.saveCheckpointOnExit()
+			}
 			m.quitting = true
 			return m, tea.Quit
 		}
@@ -19,8 +19,8 @@ func (m *ProcessModel) startAutoClose() tea.Cmd {
 // startProcessing returns a command that begins file processing
 func (m *ProcessModel) startProcessing() tea.Cmd {
 	return func() tea.Msg {
-		// Initialize checkpoint if enabled
-		if m.cfg.CheckpointInterval > 0 && m.checkpointMgr != nil {
+		// Initialize checkpoint if enabled (a resumed checkpoint keeps its history)
+		if m.cfg.CheckpointInterval > 0 && m.checkpointMgr != nil && !m.resumed {
 			if err := m.checkpointMgr.Create(m.cfg, m.totalFiles); err != nil {
 				m.logCheckpointWarning("failed to create checkpoint: %v", err)
 			}
@@ -45,11 +45,15 @@ func (m *ProcessModel) processFile(file string) {
 	m.sem <- struct{}{}        // Acquire token
 	defer func() { <-m.sem }() // Release token
 
+	var res mkv.Result
 	var err error
-	if m.cfg.Mode == "extract" {
-		err = mkv.RunExtract(file, m.cfg)
-	} else {
-		err = mkv.RunMerge(file, m.cfg)
+	switch m.cfg.Mode {
+	case "extract":
+		res, err = mkv.RunExtract(file, m.cfg)
+	case "verify":
+		res, err = mkv.RunVerify(file, m.cfg)
+	default:
+		res, err = mkv.RunMerge(file, m.cfg)
 	}
 
 	m.mu.Lock()
@@ -63,7 +67,7 @@ func (m *ProcessModel) processFile(file string) {
 			logLine = fmt.Sprintf("⏭️  SKIPPED: %s", filename)
 			m.skippedCount++
 			if m.cfg.CheckpointInterval > 0 && m.checkpointMgr != nil {
-				if addErr := m.checkpointMgr.AddSkipped(file, "no assets found"); addErr != nil {
+				if addErr := m.checkpointMgr.AddSkipped(file, m.skipReason()); addErr != nil {
 					m.logCheckpointWarningLocked("failed to record skipped file %s: %v", filename, addErr)
 				}
 			}
@@ -93,9 +97,17 @@ func (m *ProcessModel) processFile(file string) {
 				lang = m.cfg.Languages[0]
 			}
 			subsDir := filepath.Join(filepath.Dir(file), "subs", lang)
+			if lang == mkv.AllLanguages {
+				subsDir = filepath.Join(filepath.Dir(file), "subs")
+			}
 			if !contains(m.extractedPaths, subsDir) {
 				m.extractedPaths = append(m.extractedPaths, subsDir)
 			}
+			for _, l := range res.Languages {
+				if !contains(m.languages, l) {
+					m.languages = append(m.languages, l)
+				}
+			}
 		case "merge":
 			outRoot := m.cfg.OutDir
 			if outRoot == "" {
@@ -109,6 +121,7 @@ func (m *ProcessModel) processFile(file string) {
 		}
 	}
 
+	m.logs = append(m.logs, res.Notes...)
 	m.logs = append(m.logs, logLine)
 	m.processedIdx++
 
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"mkvtea/internal/checkpoint"
 	"mkvtea/internal/config"
+	"mkvtea/internal/mkv"
 	"os"
+	"sort"
 	"strings"
 
 	tea "charm.land/bubbletea/v2"
@@ -19,6 +21,8 @@ func RunProcessTUI(cfg config.Config, files []string) error {
 	}
 
 	// Check for checkpoint and offer resume if enabled
+	var resumeMgr *checkpoint.Manager
+	var earlier checkpoint.ProcessedFiles // results saved before the interruption
 	if cfg.CheckpointInterval > 0 {
 		var processed, remaining, total int
 
@@ -52,10 +56,19 @@ func RunProcessTUI(cfg config.Config, files []string) error {
 				if err != nil {
 					fmt.Printf("⚠️ Failed to initialize checkpoint manager, starting fresh: %v\n", err)
 				} else {
-					if _, err := manager.Load(); err != nil {
+					if cp, err := manager.Load(); err != nil {
 						fmt.Printf("⚠️ Failed to load checkpoint, starting fresh: %v\n", err)
 					} else {
 						files = checkpoint.FilterProcessedFiles(manager, files)
+						resumeMgr = manager
+						if cp != nil {
+							// Copy: the manager keeps appending to the loaded checkpoint
+							earlier = checkpoint.ProcessedFiles{
+								Successful: append([]checkpoint.ProcessedFile(nil), cp.Processed.Successful...),
+								Failed:     append([]checkpoint.FailedFile(nil), cp.Processed.Failed...),
+								Skipped:    append([]checkpoint.SkippedFile(nil), cp.Processed.Skipped...),
+							}
+						}
 
 						if len(files) == 0 {
 							fmt.Println("✅ All files have been processed!")
@@ -80,6 +93,11 @@ func RunProcessTUI(cfg config.Config, files []string) error {
 	}
 
 	model := NewProcessModel(cfg, files)
+	if resumeMgr != nil {
+		// Keep recording into the loaded checkpoint so earlier results survive another interruption
+		model.checkpointMgr = resumeMgr
+		model.resumed = true
+	}
 	p := tea.NewProgram(model)
 
 	finalModel, err := p.Run()
@@ -94,9 +112,27 @@ func RunProcessTUI(cfg config.Config, files []string) error {
 	fmt.Println()
 	fmt.Println("==================================================")
 	fmt.Println("📊 FINAL SUMMARY:")
-	fmt.Printf("   ✅ Success: %d\n", pm.successCount)
-	fmt.Printf("   ⏭️  Skipped: %d\n", pm.skippedCount)
-	fmt.Printf("   ❌ Errors:  %d\n", pm.errorCount)
+	successLabel, skippedLabel, failedLabel := pm.resultLabels()
+	fmt.Printf("   ✅ %-9s %d\n", successLabel+":", pm.successCount+len(earlier.Successful))
+	fmt.Printf("   ⏭️  %-9s %d\n", skippedLabel+":", pm.skippedCount+len(earlier.Skipped))
+	fmt.Printf("   ❌ %-9s %d\n", failedLabel+":", pm.errorCount+len(earlier.Failed))
+
+	// Results of the interrupted run were only shown in its own log
+	if n := len(earlier.Successful) + len(earlier.Skipped) + len(earlier.Failed); n > 0 {
+		fmt.Printf("   📋 %d of these from the checkpoint:\n", n)
+		for _, f := range earlier.Failed {
+			fmt.Printf("      ❌ %s - %s\n", f.Name, f.Error)
+		}
+		for _, f := range earlier.Skipped {
+			fmt.Printf("      ⏭️  %s - %s\n", f.Name, f.Reason)
+		}
+	}
+
+	// Languages found when extracting everything
+	if cfg.Lang == mkv.AllLanguages && len(pm.languages) > 0 {
+		sort.Strings(pm.languages)
+		fmt.Printf("   🌐 Languages: %s\n", strings.Join(pm.languages, ", "))
+	}
 
 	// Show checkpoint info
 	if cfg.CheckpointInterval > 0 {
@@ -26,9 +26,10 @@ func (m *ProcessModel) View() tea.View {
 	header := titleStyle.Render(headerText)
 
 	// === STATS BOX ===
+	successLabel, skippedLabel, failedLabel := m.resultLabels()
 	statsContent := fmt.Sprintf(
-		"📦  %2d Total  │  ✅  %2d Success  │  ⏭️  %2d Skipped  │  ❌  %2d Failed",
-		m.totalFiles, m.successCount, m.skippedCount, m.errorCount)
+		"📦  %2d Total  │  ✅  %2d %s  │  ⏭️  %2d %s  │  ❌  %2d %s",
+		m.totalFiles, m.successCount, successLabel, m.skippedCount, skippedLabel, m.errorCount, failedLabel)
 	statsBox := statsBoxStyle.Render(statsContent)
 
 	// === PROGRESS BOX ===